@@ -0,0 +1,135 @@
+package lmsensors
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A Samples value identifies a kind of sensor whose readings are averaged
+// over a configurable number of samples by the device.
+type Samples string
+
+// All possible Samples constants.  Samples correspond to the prefix of
+// their attribute in the /sys filesystem, e.g. "temp_samples".
+const (
+	SamplesCurrent     Samples = "curr"
+	SamplesPower       Samples = "power"
+	SamplesTemperature Samples = "temp"
+	SamplesVoltage     Samples = "in"
+)
+
+// SetUpdateInterval sets the interval at which the Device updates its
+// sensor readings.  Shorter intervals typically reduce accuracy.
+//
+// Drivers round the interval to one supported by the hardware, so the
+// applied interval is read back from the device and returned.  Writing
+// chip attributes typically requires root privileges; if permission is
+// denied, a *PermissionError is returned.
+func (d *Device) SetUpdateInterval(interval time.Duration) (time.Duration, error) {
+	// Raw update intervals are in milliseconds
+	ms, err := d.setInt("update_interval", int(interval/time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// SetSamples sets the number of samples over which the Device averages
+// readings for sensors of kind k.  Drivers may round n to a value supported
+// by the hardware, so the applied value is read back from the device and
+// returned.
+//
+// Writing chip attributes typically requires root privileges; if
+// permission is denied, a *PermissionError is returned.
+func (d *Device) SetSamples(k Samples, n int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("lmsensors: invalid number of samples %d", n)
+	}
+
+	return d.setInt(string(k)+"_samples", n)
+}
+
+// SetBeepEnable enables or disables all beeps generated by the Device.  The
+// applied value is read back from the device and returned.
+//
+// Writing chip attributes typically requires root privileges; if
+// permission is denied, a *PermissionError is returned.
+func (d *Device) SetBeepEnable(enable bool) (bool, error) {
+	v, err := d.setInt("beep_enable", boolInt(enable))
+	if err != nil {
+		return false, err
+	}
+
+	return v != 0, nil
+}
+
+// SetBeep enables or disables the beep generated when Sensor s raises an
+// alarm.  The applied value is read back from the device, stored in s, and
+// returned.  Beeps are only generated if they are also enabled for the
+// Device; see SetBeepEnable.
+//
+// The following sensors support beeps:
+//   - FanSensor
+//   - IntrusionSensor
+//   - TemperatureSensor
+//   - VoltageSensor
+//
+// Writing beep attributes typically requires root privileges; if
+// permission is denied, a *PermissionError is returned.
+func (d *Device) SetBeep(s Sensor, enable bool) (bool, error) {
+	set, ok := beepField(s)
+	if !ok {
+		return false, fmt.Errorf("lmsensors: sensor %q does not support beeps", s.name())
+	}
+
+	v, err := d.setInt(s.name()+"_beep", boolInt(enable))
+	if err != nil {
+		return false, err
+	}
+
+	set(v != 0)
+	return v != 0, nil
+}
+
+// beepField returns a function which sets the Beep field of Sensor s.  If
+// s does not support beeps, beepField returns false.
+func beepField(s Sensor) (func(v bool), bool) {
+	switch s := s.(type) {
+	case *FanSensor:
+		return func(v bool) { s.Beep = v }, true
+	case *IntrusionSensor:
+		return func(v bool) { s.Beep = v }, true
+	case *TemperatureSensor:
+		return func(v bool) { s.Beep = v }, true
+	case *VoltageSensor:
+		return func(v bool) { s.Beep = v }, true
+	}
+
+	return nil, false
+}
+
+// setInt writes integer v to the attribute file, and reads back the value
+// which was applied by the driver.
+func (d *Device) setInt(file string, v int) (int, error) {
+	if err := d.writeAttribute(file, strconv.Itoa(v)); err != nil {
+		return 0, err
+	}
+
+	s, err := d.readAttribute(file)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(s)
+}
+
+// boolInt returns the integer value of a boolean attribute.
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
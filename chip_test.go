@@ -0,0 +1,141 @@
+package lmsensors
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDeviceChipSetters(t *testing.T) {
+	const dir = "/sys/devices/platform/nct6775.656"
+
+	tests := []struct {
+		name string
+		file memoryFile
+		set  func(d *Device) (interface{}, error)
+		raw  string
+		out  interface{}
+		err  bool
+		perm bool
+	}{
+		{
+			name: "update interval rounded by driver",
+			file: memoryFile{
+				name:    dir + "/update_interval",
+				onWrite: func(string) string { return "1000" },
+			},
+			set: func(d *Device) (interface{}, error) {
+				return d.SetUpdateInterval(800 * time.Millisecond)
+			},
+			raw: "1000",
+			out: 1 * time.Second,
+		},
+		{
+			name: "temperature samples",
+			file: memoryFile{name: dir + "/temp_samples"},
+			set: func(d *Device) (interface{}, error) {
+				return d.SetSamples(SamplesTemperature, 4)
+			},
+			raw: "4",
+			out: 4,
+		},
+		{
+			name: "invalid samples",
+			set: func(d *Device) (interface{}, error) {
+				return d.SetSamples(SamplesVoltage, 0)
+			},
+			err: true,
+		},
+		{
+			name: "beep enable",
+			file: memoryFile{name: dir + "/beep_enable"},
+			set: func(d *Device) (interface{}, error) {
+				return d.SetBeepEnable(true)
+			},
+			raw: "1",
+			out: true,
+		},
+		{
+			name: "permission denied",
+			file: memoryFile{
+				name:     dir + "/beep_enable",
+				writeErr: &os.PathError{Op: "open", Path: dir + "/beep_enable", Err: os.ErrPermission},
+			},
+			set: func(d *Device) (interface{}, error) {
+				return d.SetBeepEnable(false)
+			},
+			err:  true,
+			perm: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &memoryFilesystem{
+				files: []memoryFile{tt.file},
+			}
+
+			d := &Device{
+				Path: dir,
+				fs:   fs,
+			}
+
+			out, err := tt.set(d)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				if _, ok := err.(*PermissionError); ok != tt.perm {
+					t.Fatalf("unexpected error type: %#v", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.raw, fs.contents(tt.file.name); want != got {
+				t.Fatalf("unexpected raw value:\n- want: %q\n-  got: %q", want, got)
+			}
+
+			if want, got := tt.out, out; want != got {
+				t.Fatalf("unexpected applied value: %v != %v", want, got)
+			}
+		})
+	}
+}
+
+func TestDeviceSetBeep(t *testing.T) {
+	const dir = "/sys/devices/platform/nct6775.656"
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{name: dir + "/fan1_beep", contents: "0"},
+		},
+	}
+
+	d := &Device{
+		Path: dir,
+		fs:   fs,
+	}
+
+	s := &FanSensor{Name: "fan1"}
+	on, err := d.SetBeep(s, true)
+	if err != nil {
+		t.Fatalf("failed to set beep: %v", err)
+	}
+
+	if !on || !s.Beep {
+		t.Fatalf("expected beep to be enabled: %v, %v", on, s.Beep)
+	}
+
+	if want, got := "1", fs.contents(dir+"/fan1_beep"); want != got {
+		t.Fatalf("unexpected fan1_beep: %q != %q", want, got)
+	}
+
+	if _, err := d.SetBeep(&PowerSensor{Name: "power1"}, true); err == nil {
+		t.Fatal("expected an error for power sensor, but none occurred")
+	}
+}
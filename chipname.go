@@ -0,0 +1,273 @@
+package lmsensors
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A BusType is the type of bus to which a chip is attached.
+type BusType string
+
+// All possible BusType constants.  BusAny is only used in patterns, and
+// matches a chip on any bus.
+const (
+	BusAny     BusType = "*"
+	BusISA     BusType = "isa"
+	BusI2C     BusType = "i2c"
+	BusPCI     BusType = "pci"
+	BusSPI     BusType = "spi"
+	BusVirtual BusType = "virtual"
+	BusACPI    BusType = "acpi"
+	BusHID     BusType = "hid"
+	BusSCSI    BusType = "scsi"
+	BusMDIO    BusType = "mdio"
+)
+
+// ChipAny is used as the bus number or address of a ChipName pattern to
+// match a chip with any bus number or address.
+const ChipAny = -1
+
+// A ChipName is a libsensors chip name, such as "it8728-isa-0290", or a
+// pattern which matches chip names, such as "it87-*" or "*-isa-*".
+type ChipName struct {
+	// The name reported by the chip's driver, such as "it8728", or "*" to
+	// match any chip.
+	Prefix string
+
+	// The type of bus to which the chip is attached.
+	Bus BusType
+
+	// The number of the bus, for bus types which have more than one bus,
+	// such as i2c.  Zero for other bus types.
+	BusNumber int
+
+	// The address of the chip on its bus.
+	Address int
+}
+
+// ParseChipName parses a chip name or pattern, such as "it87-*",
+// "*-isa-0290", or "lm75-i2c-0-48".  Components which are omitted from the
+// end of the name match any value.
+func ParseChipName(s string) (ChipName, error) {
+	c := ChipName{
+		Bus:       BusAny,
+		BusNumber: ChipAny,
+		Address:   ChipAny,
+	}
+
+	fs := strings.Split(s, "-")
+	if fs[0] == "" {
+		return ChipName{}, fmt.Errorf("lmsensors: invalid chip name %q", s)
+	}
+	c.Prefix, fs = fs[0], fs[1:]
+
+	if len(fs) == 0 {
+		return c, nil
+	}
+	c.Bus, fs = BusType(fs[0]), fs[1:]
+
+	switch c.Bus {
+	case BusAny:
+		// A wildcard bus matches chips at any address
+		if len(fs) > 0 {
+			return ChipName{}, fmt.Errorf("lmsensors: invalid chip name %q", s)
+		}
+	case BusISA, BusPCI, BusVirtual, BusACPI, BusMDIO:
+		c.BusNumber = 0
+	case BusI2C, BusSPI, BusHID, BusSCSI:
+		if len(fs) > 0 {
+			n, err := parseChipNumber(fs[0], 10)
+			if err != nil {
+				return ChipName{}, fmt.Errorf("lmsensors: invalid bus number in chip name %q", s)
+			}
+
+			c.BusNumber, fs = n, fs[1:]
+		}
+	default:
+		return ChipName{}, fmt.Errorf("lmsensors: invalid bus type in chip name %q", s)
+	}
+
+	if len(fs) > 0 {
+		a, err := parseChipNumber(fs[0], 16)
+		if err != nil {
+			return ChipName{}, fmt.Errorf("lmsensors: invalid address in chip name %q", s)
+		}
+
+		c.Address, fs = a, fs[1:]
+	}
+
+	if len(fs) > 0 {
+		return ChipName{}, fmt.Errorf("lmsensors: invalid chip name %q", s)
+	}
+
+	return c, nil
+}
+
+// parseChipNumber parses a bus number or address in the specified base, or
+// "*" for ChipAny.
+func parseChipNumber(s string, base int) (int, error) {
+	if s == "*" {
+		return ChipAny, nil
+	}
+
+	n, err := strconv.ParseUint(s, base, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// String returns the chip name in the format used by libsensors.
+func (c ChipName) String() string {
+	if c.Bus == BusAny {
+		return c.Prefix + "-*"
+	}
+
+	s := c.Prefix + "-" + string(c.Bus)
+
+	switch c.Bus {
+	case BusI2C, BusSPI, BusHID, BusSCSI:
+		s += "-" + chipNumber(c.BusNumber, "%d")
+	}
+
+	switch c.Bus {
+	case BusISA, BusPCI:
+		return s + "-" + chipNumber(c.Address, "%04x")
+	case BusI2C:
+		return s + "-" + chipNumber(c.Address, "%02x")
+	default:
+		return s + "-" + chipNumber(c.Address, "%x")
+	}
+}
+
+// chipNumber formats a bus number or address, or "*" for ChipAny.
+func chipNumber(n int, format string) string {
+	if n == ChipAny {
+		return "*"
+	}
+
+	return fmt.Sprintf(format, n)
+}
+
+// Match determines if the ChipName pattern matches Device d.
+func (c ChipName) Match(d *Device) bool {
+	dc := d.ChipName()
+
+	if c.Prefix != "*" && c.Prefix != dc.Prefix {
+		return false
+	}
+
+	if c.Bus != BusAny && c.Bus != dc.Bus {
+		return false
+	}
+
+	if c.BusNumber != ChipAny && c.BusNumber != dc.BusNumber {
+		return false
+	}
+
+	return c.Address == ChipAny || c.Address == dc.Address
+}
+
+var (
+	// Device names for devices on each bus, as they appear in the /sys
+	// filesystem.
+	i2cDevice = regexp.MustCompile(`^(\d+)-([0-9a-f]{4})$`)
+	pciDevice = regexp.MustCompile(`^([0-9a-f]{4}):([0-9a-f]{2}):([0-9a-f]{2})\.([0-7])$`)
+	spiDevice = regexp.MustCompile(`^spi(\d+)\.(\d+)$`)
+	hidDevice = regexp.MustCompile(`^([0-9A-F]{4}):[0-9A-F]{4}:[0-9A-F]{4}\.([0-9A-F]{4})$`)
+
+	// SCSI devices are named by host, channel, target, and LUN.
+	scsiDevice = regexp.MustCompile(`^(\d+):(\d+):(\d+):(\d+)$`)
+
+	// MDIO devices are named by bus and address, such as "stmmac-0:01", but
+	// only identified by their subsystem.
+	mdioDevice = regexp.MustCompile(`^[^:]+:([0-9a-f]+)$`)
+)
+
+// ChipName returns the libsensors chip name of the Device, such as
+// "it8728-isa-0290", determined from its name and DevicePath.  Devices
+// with no DevicePath are virtual, and MDIO devices are identified by the
+// subsystem of their hardware.  If the bus of the Device cannot be
+// determined, its Bus is BusAny, and it is only matched by patterns which
+// match any bus.  Devices parsed from the output of the "sensors" command
+// keep the chip name they were parsed from.
+func (d *Device) ChipName() ChipName {
+	if d.chipName != nil {
+		return *d.chipName
+	}
+
+	c := ChipName{
+		Prefix:  d.chip(),
+		Bus:     BusAny,
+		Address: ChipAny,
+	}
+
+	if d.DevicePath == "" {
+		c.Bus, c.Address = BusVirtual, 0
+		return c
+	}
+
+	dev := filepath.Base(d.DevicePath)
+
+	switch {
+	case d.subsystem == "mdio_bus" && mdioDevice.MatchString(dev):
+		m := mdioDevice.FindStringSubmatch(dev)
+		a, _ := strconv.ParseUint(m[1], 16, 16)
+		c.Bus, c.Address = BusMDIO, int(a)
+	case i2cDevice.MatchString(dev):
+		m := i2cDevice.FindStringSubmatch(dev)
+		c.Bus = BusI2C
+		c.BusNumber, _ = strconv.Atoi(m[1])
+		a, _ := strconv.ParseUint(m[2], 16, 16)
+		c.Address = int(a)
+	case pciDevice.MatchString(dev):
+		// libsensors packs the PCI domain, bus, slot, and function into the
+		// address
+		m := pciDevice.FindStringSubmatch(dev)
+		var n [4]uint64
+		for i := range n {
+			n[i], _ = strconv.ParseUint(m[i+1], 16, 16)
+		}
+
+		c.Bus = BusPCI
+		c.Address = int(n[0]<<16 + n[1]<<8 + n[2]<<3 + n[3])
+	case spiDevice.MatchString(dev):
+		m := spiDevice.FindStringSubmatch(dev)
+		c.Bus = BusSPI
+		c.BusNumber, _ = strconv.Atoi(m[1])
+		c.Address, _ = strconv.Atoi(m[2])
+	case hidDevice.MatchString(dev):
+		m := hidDevice.FindStringSubmatch(dev)
+		b, _ := strconv.ParseUint(m[1], 16, 16)
+		a, _ := strconv.ParseUint(m[2], 16, 16)
+		c.Bus, c.BusNumber, c.Address = BusHID, int(b), int(a)
+	case scsiDevice.MatchString(dev):
+		// libsensors numbers the bus by SCSI host, and packs the channel,
+		// target, and LUN into the address
+		m := scsiDevice.FindStringSubmatch(dev)
+		var n [4]int
+		for i := range n {
+			n[i], _ = strconv.Atoi(m[i+1])
+		}
+
+		c.Bus, c.BusNumber = BusSCSI, n[0]
+		c.Address = n[1]<<8 + n[2]<<4 + n[3]
+	case strings.Contains(d.DevicePath, "/platform/"):
+		// Other platform devices are treated as ISA devices, numbered by
+		// the suffix of their device name, e.g. "it87.656"
+		c.Bus, c.Address = BusISA, 0
+		if i := strings.LastIndex(dev, "."); i != -1 {
+			if n, err := strconv.Atoi(dev[i+1:]); err == nil {
+				c.Address = n
+			}
+		}
+	case strings.Contains(d.DevicePath, "/LNXSYSTM:"):
+		c.Bus, c.Address = BusACPI, 0
+	}
+
+	return c
+}
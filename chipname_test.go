@@ -0,0 +1,190 @@
+package lmsensors
+
+import (
+	"testing"
+)
+
+func TestParseChipName(t *testing.T) {
+	tests := []struct {
+		s   string
+		c   ChipName
+		out string
+		err bool
+	}{
+		{
+			s:   "it87-*",
+			c:   ChipName{Prefix: "it87", Bus: BusAny, BusNumber: ChipAny, Address: ChipAny},
+			out: "it87-*",
+		},
+		{
+			s:   "it87",
+			c:   ChipName{Prefix: "it87", Bus: BusAny, BusNumber: ChipAny, Address: ChipAny},
+			out: "it87-*",
+		},
+		{
+			s:   "*-isa-0290",
+			c:   ChipName{Prefix: "*", Bus: BusISA, Address: 0x290},
+			out: "*-isa-0290",
+		},
+		{
+			s:   "nct6775-isa-*",
+			c:   ChipName{Prefix: "nct6775", Bus: BusISA, Address: ChipAny},
+			out: "nct6775-isa-*",
+		},
+		{
+			s:   "lm75-i2c-0-48",
+			c:   ChipName{Prefix: "lm75", Bus: BusI2C, BusNumber: 0, Address: 0x48},
+			out: "lm75-i2c-0-48",
+		},
+		{
+			s:   "lm75-i2c-*-4c",
+			c:   ChipName{Prefix: "lm75", Bus: BusI2C, BusNumber: ChipAny, Address: 0x4c},
+			out: "lm75-i2c-*-4c",
+		},
+		{
+			s:   "acpitz-virtual-0",
+			c:   ChipName{Prefix: "acpitz", Bus: BusVirtual, Address: 0},
+			out: "acpitz-virtual-0",
+		},
+		{
+			s:   "drivetemp-scsi-0-0",
+			c:   ChipName{Prefix: "drivetemp", Bus: BusSCSI, BusNumber: 0, Address: 0},
+			out: "drivetemp-scsi-0-0",
+		},
+		{
+			s:   "drivetemp-scsi-*",
+			c:   ChipName{Prefix: "drivetemp", Bus: BusSCSI, BusNumber: ChipAny, Address: ChipAny},
+			out: "drivetemp-scsi-*-*",
+		},
+		{
+			s:   "drivetemp-scsi-4-10",
+			c:   ChipName{Prefix: "drivetemp", Bus: BusSCSI, BusNumber: 4, Address: 0x10},
+			out: "drivetemp-scsi-4-10",
+		},
+		{
+			s:   "bcm54xx-mdio-1",
+			c:   ChipName{Prefix: "bcm54xx", Bus: BusMDIO, Address: 1},
+			out: "bcm54xx-mdio-1",
+		},
+		{s: "", err: true},
+		{s: "it87-usb-0", err: true},
+		{s: "it87-*-0290", err: true},
+		{s: "lm75-i2c-x-48", err: true},
+		{s: "it87-isa-zzzz", err: true},
+		{s: "it87-isa-0290-1", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			c, err := ParseChipName(tt.s)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.c, c; want != got {
+				t.Fatalf("unexpected ChipName:\n- want: %#v\n-  got: %#v", want, got)
+			}
+
+			if want, got := tt.out, c.String(); want != got {
+				t.Fatalf("unexpected string: %q != %q", want, got)
+			}
+		})
+	}
+}
+
+func TestDeviceChipName(t *testing.T) {
+	tests := []struct {
+		d    *Device
+		name string
+	}{
+		{
+			d:    &Device{Name: "acpitz-00"},
+			name: "acpitz-virtual-0",
+		},
+		{
+			d:    &Device{Name: "it8728-00", DevicePath: "/sys/devices/platform/it87.656"},
+			name: "it8728-isa-0290",
+		},
+		{
+			d:    &Device{Name: "coretemp-01", DevicePath: "/sys/devices/platform/coretemp.1"},
+			name: "coretemp-isa-0001",
+		},
+		{
+			d:    &Device{Name: "lm75-00", DevicePath: "/sys/devices/platform/soc/1c2ac00.i2c/i2c-1/1-0048"},
+			name: "lm75-i2c-1-48",
+		},
+		{
+			d:    &Device{Name: "k10temp-00", DevicePath: "/sys/devices/pci0000:00/0000:00:18.3"},
+			name: "k10temp-pci-00c3",
+		},
+		{
+			d:    &Device{Name: "power_meter-00", DevicePath: "/sys/devices/LNXSYSTM:00/device:00/ACPI000D:00"},
+			name: "power_meter-acpi-0",
+		},
+		{
+			d:    &Device{Name: "drivetemp-00", DevicePath: "/sys/devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0"},
+			name: "drivetemp-scsi-1-0",
+		},
+		{
+			d: &Device{
+				Name:       "bcm54xx-00",
+				DevicePath: "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01",
+				subsystem:  "mdio_bus",
+			},
+			name: "bcm54xx-mdio-1",
+		},
+		{
+			d:    &Device{Name: "mystery-00", DevicePath: "/sys/devices/mystery"},
+			name: "mystery-*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.name, tt.d.ChipName().String(); want != got {
+				t.Fatalf("unexpected chip name: %q != %q", want, got)
+			}
+		})
+	}
+}
+
+func TestChipNameMatch(t *testing.T) {
+	d := &Device{
+		Name:       "it8728-00",
+		DevicePath: "/sys/devices/platform/it87.656",
+	}
+
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{pattern: "*", ok: true},
+		{pattern: "it8728-*", ok: true},
+		{pattern: "*-isa-*", ok: true},
+		{pattern: "*-isa-0290", ok: true},
+		{pattern: "it8728-isa-0290", ok: true},
+		{pattern: "it87-*"},
+		{pattern: "it8728-isa-0228"},
+		{pattern: "it8728-i2c-*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			c, err := ParseChipName(tt.pattern)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+
+			if want, got := tt.ok, c.Match(d); want != got {
+				t.Fatalf("unexpected match: %v != %v", want, got)
+			}
+		})
+	}
+}
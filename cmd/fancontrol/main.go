@@ -0,0 +1,47 @@
+// Command fancontrol controls fan speeds in response to temperatures, using
+// a configuration file in the format of the lm-sensors fancontrol script.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/fancontrol"
+)
+
+func main() {
+	config := flag.String("c", "/etc/fancontrol", "path to fancontrol configuration file")
+	flag.Parse()
+
+	f, err := os.Open(*config)
+	if err != nil {
+		log.Fatalf("failed to open configuration: %v", err)
+	}
+
+	cfg, err := fancontrol.Parse(f)
+	_ = f.Close()
+	if err != nil {
+		log.Fatalf("failed to parse configuration: %v", err)
+	}
+
+	c, err := fancontrol.New(cfg, lmsensors.New())
+	if err != nil {
+		log.Fatalf("failed to create controller: %v", err)
+	}
+
+	// Fans are set to full speed when the controller stops
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer cancel()
+
+	log.Printf("controlling %d PWM outputs every %s", len(cfg.Outputs), cfg.Interval)
+
+	if err := c.Run(ctx); err != nil {
+		log.Fatalf("failed to control fans: %v", err)
+	}
+}
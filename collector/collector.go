@@ -0,0 +1,99 @@
+// Package collector implements a Prometheus collector for lmsensors
+// Devices, which exports metrics compatible with the hwmon collector of the
+// Prometheus node_exporter.
+package collector
+
+import (
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/hwmon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Scanner scans for Devices.  It is implemented by *lmsensors.Scanner.
+type Scanner interface {
+	ScanResults() ([]*lmsensors.ScanResult, error)
+}
+
+var _ Scanner = &lmsensors.Scanner{}
+
+// A Collector is a prometheus.Collector which scans for Devices each time
+// it is collected.
+//
+// Readings and limits are exported with the names used by node_exporter,
+// such as node_hwmon_temp_celsius and node_hwmon_fan_min_rpm, with "chip"
+// and "sensor" labels.  The label of each Sensor is exported as
+// node_hwmon_sensor_label, and the chip name of each Device as
+// node_hwmon_chip_names, so they can be joined with readings.  As in
+// node_exporter, alarms and beeps are exported without a unit suffix, such
+// as node_hwmon_temp_alarm, but other alarms are named like the limits
+// they belong to, such as node_hwmon_temp_crit_alarm_celsius.
+//
+// The time taken to scan each Device, and whether or not scanning it
+// failed, are exported as node_hwmon_scrape_duration_seconds and
+// node_hwmon_scrape_error.
+type Collector struct {
+	s Scanner
+
+	scrapeError *prometheus.Desc
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// New creates a Collector which scans for Devices using s.
+func New(s Scanner) *Collector {
+	return &Collector{
+		s: s,
+
+		scrapeError: prometheus.NewDesc(
+			hwmon.Namespace+"_scrape_error",
+			"Whether or not an error occurred while scanning a chip",
+			[]string{"chip"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.  The metrics exported by a
+// Collector depend on the Sensors of each Device, so no descriptors are
+// sent, and the Collector is unchecked.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	results, err := c.s.ScanResults()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.scrapeError, err)
+		return
+	}
+
+	devices := make([]*lmsensors.Device, 0, len(results))
+	for _, r := range results {
+		devices = append(devices, r.Device)
+	}
+
+	for i, chip := range hwmon.ChipLabels(devices) {
+		r := results[i]
+
+		samples := hwmon.ScrapeSamples(r, chip)
+		if r.Err == nil {
+			samples = append(samples, hwmon.DeviceSamples(r.Device, chip)...)
+		}
+
+		for _, s := range samples {
+			ch <- metric(s)
+		}
+	}
+}
+
+// metric creates a gauge metric from a sample.
+func metric(s hwmon.Sample) prometheus.Metric {
+	names := make([]string, 0, len(s.Labels))
+	values := make([]string, 0, len(s.Labels))
+	for _, l := range s.Labels {
+		names = append(names, l.Name)
+		values = append(values, l.Value)
+	}
+
+	desc := prometheus.NewDesc(s.Name, s.Help, names, nil)
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, s.Value, values...)
+}
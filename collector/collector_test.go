@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/lmsensorstest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	s := &lmsensorstest.Scanner{
+		Results: []*lmsensors.ScanResult{
+			{
+				Device: &lmsensors.Device{
+					Name:       "it8728-00",
+					Hwmon:      "hwmon1",
+					DevicePath: "/sys/devices/platform/it87.2608",
+					Sensors: []lmsensors.Sensor{
+						&lmsensors.FanSensor{
+							Name:    "fan1",
+							Input:   1130,
+							Minimum: 300,
+						},
+						&lmsensors.VoltageSensor{
+							Name:  "in0",
+							Label: "Vcore",
+							Input: 0.696,
+							// A minimum of zero is still reported
+							Attributes: map[string]bool{
+								"alarm": true,
+								"min":   true,
+							},
+						},
+						&lmsensors.PWMSensor{
+							Name:   "pwm1",
+							Enable: lmsensors.PWMEnableAutomatic,
+							Duty:   128,
+						},
+						&lmsensors.TemperatureSensor{
+							Name:          "temp1",
+							Type:          lmsensors.TemperatureSensorTypeThermistor,
+							Input:         43.0,
+							Critical:      100.0,
+							CriticalAlarm: true,
+						},
+					},
+				},
+				Duration: 250 * time.Millisecond,
+			},
+			{
+				Device: &lmsensors.Device{
+					Name:  "power_meter-00",
+					Hwmon: "hwmon2",
+					Sensors: []lmsensors.Sensor{
+						&lmsensors.PowerSensor{
+							Name:            "power1",
+							Average:         345.0,
+							AverageInterval: 1 * time.Second,
+						},
+					},
+				},
+				Duration: 100 * time.Millisecond,
+			},
+			{
+				Device: &lmsensors.Device{
+					Name:  "broken-00",
+					Hwmon: "hwmon3",
+				},
+				Duration: 500 * time.Millisecond,
+				Err:      errors.New("broken"),
+			},
+		},
+	}
+
+	const want = `
+# HELP node_hwmon_chip_names Annotation metric for human-readable chip names
+# TYPE node_hwmon_chip_names gauge
+node_hwmon_chip_names{chip="platform_it87_2608",chip_name="it8728"} 1
+node_hwmon_chip_names{chip="power_meter",chip_name="power_meter"} 1
+# HELP node_hwmon_fan_alarm Hardware sensor alarm status (fan)
+# TYPE node_hwmon_fan_alarm gauge
+node_hwmon_fan_alarm{chip="platform_it87_2608",sensor="fan1"} 0
+# HELP node_hwmon_fan_min_rpm Hardware monitor for fan revolutions per minute (min)
+# TYPE node_hwmon_fan_min_rpm gauge
+node_hwmon_fan_min_rpm{chip="platform_it87_2608",sensor="fan1"} 300
+# HELP node_hwmon_fan_rpm Hardware monitor for fan revolutions per minute (input)
+# TYPE node_hwmon_fan_rpm gauge
+node_hwmon_fan_rpm{chip="platform_it87_2608",sensor="fan1"} 1130
+# HELP node_hwmon_in_alarm Hardware sensor alarm status (in)
+# TYPE node_hwmon_in_alarm gauge
+node_hwmon_in_alarm{chip="platform_it87_2608",sensor="in0"} 0
+# HELP node_hwmon_in_min_volts Hardware monitor for voltage (min)
+# TYPE node_hwmon_in_min_volts gauge
+node_hwmon_in_min_volts{chip="platform_it87_2608",sensor="in0"} 0
+# HELP node_hwmon_in_volts Hardware monitor for voltage (input)
+# TYPE node_hwmon_in_volts gauge
+node_hwmon_in_volts{chip="platform_it87_2608",sensor="in0"} 0.696
+# HELP node_hwmon_power_average_interval_seconds Hardware monitor power usage update interval (average_interval)
+# TYPE node_hwmon_power_average_interval_seconds gauge
+node_hwmon_power_average_interval_seconds{chip="power_meter",sensor="power1"} 1
+# HELP node_hwmon_power_average_watt Hardware monitor for power usage in watts (average)
+# TYPE node_hwmon_power_average_watt gauge
+node_hwmon_power_average_watt{chip="power_meter",sensor="power1"} 345
+# HELP node_hwmon_pwm Hardware monitor pwm element 
+# TYPE node_hwmon_pwm gauge
+node_hwmon_pwm{chip="platform_it87_2608",sensor="pwm1"} 128
+# HELP node_hwmon_pwm_enable Hardware monitor pwm element enable
+# TYPE node_hwmon_pwm_enable gauge
+node_hwmon_pwm_enable{chip="platform_it87_2608",sensor="pwm1"} 2
+# HELP node_hwmon_scrape_duration_seconds Time taken to scan a chip
+# TYPE node_hwmon_scrape_duration_seconds gauge
+node_hwmon_scrape_duration_seconds{chip="broken"} 0.5
+node_hwmon_scrape_duration_seconds{chip="platform_it87_2608"} 0.25
+node_hwmon_scrape_duration_seconds{chip="power_meter"} 0.1
+# HELP node_hwmon_scrape_error Whether or not an error occurred while scanning a chip
+# TYPE node_hwmon_scrape_error gauge
+node_hwmon_scrape_error{chip="broken"} 1
+node_hwmon_scrape_error{chip="platform_it87_2608"} 0
+node_hwmon_scrape_error{chip="power_meter"} 0
+# HELP node_hwmon_sensor_label Label for given chip and sensor
+# TYPE node_hwmon_sensor_label gauge
+node_hwmon_sensor_label{chip="platform_it87_2608",label="Vcore",sensor="in0"} 1
+# HELP node_hwmon_temp_alarm Hardware sensor alarm status (temp)
+# TYPE node_hwmon_temp_alarm gauge
+node_hwmon_temp_alarm{chip="platform_it87_2608",sensor="temp1"} 0
+# HELP node_hwmon_temp_celsius Hardware monitor for temperature (input)
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_it87_2608",sensor="temp1"} 43
+# HELP node_hwmon_temp_crit_alarm_celsius Hardware monitor for temperature (crit_alarm)
+# TYPE node_hwmon_temp_crit_alarm_celsius gauge
+node_hwmon_temp_crit_alarm_celsius{chip="platform_it87_2608",sensor="temp1"} 1
+# HELP node_hwmon_temp_crit_celsius Hardware monitor for temperature (crit)
+# TYPE node_hwmon_temp_crit_celsius gauge
+node_hwmon_temp_crit_celsius{chip="platform_it87_2608",sensor="temp1"} 100
+# HELP node_hwmon_temp_type Hardware monitor temp element type
+# TYPE node_hwmon_temp_type gauge
+node_hwmon_temp_type{chip="platform_it87_2608",sensor="temp1"} 4
+`
+
+	if err := testutil.CollectAndCompare(New(s), strings.NewReader(want)); err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
+
+func TestCollectorScanError(t *testing.T) {
+	s := &lmsensorstest.Scanner{Err: errors.New("no devices")}
+
+	if err := testutil.CollectAndCompare(New(s), strings.NewReader("")); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
@@ -0,0 +1,131 @@
+package lmsensors
+
+import (
+	"fmt"
+)
+
+// maxComputeDepth is the maximum depth of feature references followed when
+// evaluating compute statements, so that circular references terminate.
+const maxComputeDepth = 8
+
+// compute applies the forward transforms of compute statements to the
+// Sensors of Device d.  Feature references in expressions resolve to the
+// transformed primary reading of the referenced Sensor.
+func (d *Device) compute(computes map[string]*ComputeStatement) error {
+	// Gather raw readings before any are transformed, so references do not
+	// depend on the order in which Sensors are processed
+	raw := make(map[string]float64)
+	for _, s := range d.Sensors {
+		if fs := computeFields(s); len(fs) > 0 {
+			raw[s.name()] = *fs[0].v
+		}
+	}
+
+	var value func(name string, depth int) (float64, error)
+	value = func(name string, depth int) (float64, error) {
+		if depth > maxComputeDepth {
+			return 0, fmt.Errorf("feature references nested too deeply")
+		}
+
+		v, ok := raw[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown feature %q", name)
+		}
+
+		cs, ok := computes[name]
+		if !ok {
+			return v, nil
+		}
+
+		return cs.From.Eval(v, func(name string) (float64, error) {
+			return value(name, depth+1)
+		})
+	}
+
+	features := func(name string) (float64, error) {
+		return value(name, 1)
+	}
+
+	for _, s := range d.Sensors {
+		cs, ok := computes[s.name()]
+		if !ok {
+			continue
+		}
+
+		for i, f := range computeFields(s) {
+			// Only transform values which were read: limits which the
+			// device does not provide, and invalid readings, are left alone
+			if !s.validity()[f.attr].OK() || (i > 0 && !present(s, f.attr, *f.v != 0)) {
+				continue
+			}
+
+			v, err := cs.From.Eval(*f.v, features)
+			if err != nil {
+				return computeError(cs, err)
+			}
+
+			*f.v = v
+		}
+	}
+
+	d.computes = computes
+	return nil
+}
+
+// feature returns the primary reading of the Sensor with the specified
+// name, for use in compute expressions.
+func (d *Device) feature(name string) (float64, error) {
+	for _, s := range d.Sensors {
+		if s.name() != name {
+			continue
+		}
+
+		if fs := computeFields(s); len(fs) > 0 {
+			return *fs[0].v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown feature %q", name)
+}
+
+// A computeField is a field of a Sensor which is transformed by compute
+// statements, and the name of the attribute it is read from.
+type computeField struct {
+	attr string
+	v    *float64
+}
+
+// computeFields returns the fields of Sensor s which are transformed by
+// compute statements, beginning with its primary reading.  If s does not
+// support compute statements, computeFields returns nil.
+func computeFields(s Sensor) []computeField {
+	switch s := s.(type) {
+	case *CurrentSensor:
+		return []computeField{
+			{"input", &s.Input}, {"max", &s.Maximum}, {"crit", &s.Critical},
+		}
+	case *PowerSensor:
+		return []computeField{{"average", &s.Average}}
+	case *TemperatureSensor:
+		return []computeField{
+			{"input", &s.Input}, {"max", &s.High}, {"crit", &s.Critical},
+			{"lowest", &s.Lowest}, {"highest", &s.Highest},
+		}
+	case *VoltageSensor:
+		return []computeField{
+			{"input", &s.Input}, {"min", &s.Minimum}, {"max", &s.Maximum},
+			{"lowest", &s.Lowest}, {"highest", &s.Highest},
+		}
+	}
+
+	return nil
+}
+
+// computeError returns a *ConfigError for an error encountered while
+// evaluating compute statement cs.
+func computeError(cs *ComputeStatement, err error) error {
+	return &ConfigError{
+		Position: cs.Position,
+		Message:  fmt.Sprintf("compute %s: %v", cs.Feature, err),
+	}
+}
@@ -0,0 +1,254 @@
+package lmsensors
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExprEval(t *testing.T) {
+	features := func(name string) (float64, error) {
+		if name == "in0" {
+			return 1.5, nil
+		}
+
+		return 0, fmt.Errorf("unknown feature %q", name)
+	}
+
+	tests := []struct {
+		s   string
+		raw float64
+		v   float64
+		err bool
+	}{
+		{s: "@*(1+120/56)", raw: 3.5, v: 3.5 * (1 + 120.0/56)},
+		{s: "-@ + 10", raw: 2, v: 8},
+		{s: "^`@", raw: 42, v: 42},
+		{s: "`^2", v: 2},
+		{s: "(@ - in0) * 2", raw: 2, v: 1},
+		{s: "@ + in9", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			c, err := ParseConfig(strings.NewReader("chip \"it87-*\"\nset in0_min "+tt.s), "")
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			v, err := c.Chips[0].Sets[0].Value.Eval(tt.raw, features)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if math.Abs(tt.v-v) > 1e-9 {
+				t.Fatalf("unexpected value: %v != %v", tt.v, v)
+			}
+		})
+	}
+}
+
+func TestConfigApplyCompute(t *testing.T) {
+	const conf = `
+chip "it8728-*"
+    compute in3 @*(1+120/56), @/(1+120/56)
+    compute in4 @ - in3, @ + in3
+    compute temp1 @ + 5, @ - 5
+    compute fan1 @*2, @/2
+    ignore in3
+`
+
+	c, err := ParseConfig(strings.NewReader(conf), "sensors3.conf")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	in4 := &VoltageSensor{Name: "in4", Input: 12.5, Maximum: 13}
+	temp1 := &TemperatureSensor{Name: "temp1", Input: 40, High: 80}
+	fan1 := &FanSensor{Name: "fan1", Input: 1200}
+
+	d := &Device{
+		Name: "it8728-00",
+		Path: "/sys/devices/platform/it87.2608",
+		fs: &memoryFilesystem{
+			files: []memoryFile{
+				{name: "/sys/devices/platform/it87.2608/fan1_min"},
+				{name: "/sys/devices/platform/it87.2608/temp1_max"},
+			},
+		},
+		Sensors: []Sensor{
+			&VoltageSensor{Name: "in3", Input: 1.68, Minimum: 1.54},
+			in4,
+			temp1,
+			fan1,
+		},
+	}
+
+	if err := c.apply(d); err != nil {
+		t.Fatalf("failed to apply config: %v", err)
+	}
+
+	if want, got := 3, len(d.Sensors); want != got {
+		t.Fatalf("unexpected number of Sensors: %d != %d", want, got)
+	}
+
+	// in3 is ignored, but its computed value is still available to in4
+	if want, got := 12.5-1.68*(1+120.0/56), in4.Input; math.Abs(want-got) > 1e-9 {
+		t.Fatalf("unexpected in4 Input: %v != %v", want, got)
+	}
+
+	if want, got := 45.0, temp1.Input; want != got {
+		t.Fatalf("unexpected temp1 Input: %v != %v", want, got)
+	}
+	if want, got := 85.0, temp1.High; want != got {
+		t.Fatalf("unexpected temp1 High: %v != %v", want, got)
+	}
+
+	// Limits are written using the inverse transform
+	out, err := d.SetLimit(temp1, LimitMaximum, 75)
+	if err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+
+	if want, got := 75.0, out; want != got {
+		t.Fatalf("unexpected limit: %v != %v", want, got)
+	}
+
+	fs := d.fs.(*memoryFilesystem)
+	if want, got := "70000", fs.contents("/sys/devices/platform/it87.2608/temp1_max"); want != got {
+		t.Fatalf("unexpected raw limit: %q != %q", want, got)
+	}
+
+	// Fan speeds are not computed
+	if want, got := 1200, fan1.Input; want != got {
+		t.Fatalf("unexpected fan1 Input: %v != %v", want, got)
+	}
+
+	if _, err := d.SetLimit(fan1, LimitMinimum, 300); err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+
+	if want, got := "300", fs.contents("/sys/devices/platform/it87.2608/fan1_min"); want != got {
+		t.Fatalf("unexpected raw fan limit: %q != %q", want, got)
+	}
+}
+
+func TestConfigApplyComputeUnreadFields(t *testing.T) {
+	const conf = `
+chip "it8728-*"
+    compute in3 @+5, @-5
+    compute temp1 @+5, @-5
+`
+
+	c, err := ParseConfig(strings.NewReader(conf), "sensors3.conf")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	// in3 has a minimum of zero but no maximum, and temp1 could not be read
+	in3 := &VoltageSensor{
+		Name:  "in3",
+		Input: 1.5,
+		Attributes: map[string]bool{
+			"min": true,
+		},
+	}
+	temp1 := &TemperatureSensor{
+		Name: "temp1",
+		High: 80,
+		Validity: map[string]Validity{
+			"input": {State: StateIOError},
+		},
+		Attributes: map[string]bool{
+			"max": true,
+		},
+	}
+
+	d := &Device{
+		Name:    "it8728-00",
+		Sensors: []Sensor{in3, temp1},
+	}
+
+	if err := c.apply(d); err != nil {
+		t.Fatalf("failed to apply config: %v", err)
+	}
+
+	want := []Sensor{
+		&VoltageSensor{
+			Name:    "in3",
+			Input:   6.5,
+			Minimum: 5,
+			Attributes: map[string]bool{
+				"min": true,
+			},
+		},
+		&TemperatureSensor{
+			Name: "temp1",
+			High: 85,
+			Validity: map[string]Validity{
+				"input": {State: StateIOError},
+			},
+			Attributes: map[string]bool{
+				"max": true,
+			},
+		},
+	}
+
+	if got := d.Sensors; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Sensors:\n- want: %#v\n-  got: %#v", want, got)
+	}
+}
+
+func TestConfigApplyComputeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		err  string
+	}{
+		{
+			name: "unknown feature",
+			conf: "chip \"it8728-*\"\ncompute in0 @ + in9, @ - in9",
+			err:  `lmsensors: sensors3.conf:2: compute in0: unknown feature "in9"`,
+		},
+		{
+			name: "circular reference",
+			conf: "chip \"it8728-*\"\ncompute in0 @ + in1, @ - in1\ncompute in1 @ + in0, @ - in0",
+			err:  "lmsensors: sensors3.conf:2: compute in0: feature references nested too deeply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig(strings.NewReader(tt.conf), "sensors3.conf")
+			if err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+
+			d := &Device{
+				Name: "it8728-00",
+				Sensors: []Sensor{
+					&VoltageSensor{Name: "in0"},
+					&VoltageSensor{Name: "in1"},
+				},
+			}
+
+			err = c.apply(d)
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := tt.err, err.Error(); want != got {
+				t.Fatalf("unexpected error:\n- want: %s\n-  got: %s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package lmsensors
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Locations of lm-sensors configuration files, in the order they are read
+// by libsensors.
+const (
+	configFile    = "/etc/sensors3.conf"
+	altConfigFile = "/etc/sensors.conf"
+	configDir     = "/etc/sensors.d"
+)
+
+// A Config is an lm-sensors configuration, typically read from
+// /etc/sensors3.conf and /etc/sensors.d/*.conf.  Statements are stored in
+// the order they were read.
+type Config struct {
+	// Bus statements, which map i2c bus numbers to adapter names.
+	Buses []*BusStatement
+
+	// Chip blocks, each of which holds the statements that apply to the
+	// chips it names.
+	Chips []*ChipBlock
+}
+
+// A Position is the location of a statement in a configuration file.
+type Position struct {
+	File string
+	Line int
+}
+
+// String returns the position in "file:line" form.
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// A BusStatement is a "bus" statement, which maps an i2c bus number used in
+// the configuration to the name of an adapter.
+type BusStatement struct {
+	// The bus as named in the configuration, such as "i2c-0".
+	Bus string
+
+	// The name of the adapter, such as "SMBus I801 adapter at 0400".
+	Adapter string
+
+	Position Position
+}
+
+// A ChipBlock is a "chip" statement, and the statements which follow it
+// until the next "chip" statement.
+type ChipBlock struct {
+	// The chip name patterns to which the block applies, such as
+	// "it87-*".
+	Names []string
+
+	Labels   []*LabelStatement
+	Computes []*ComputeStatement
+	Ignores  []*IgnoreStatement
+	Sets     []*SetStatement
+
+	Position Position
+}
+
+// A LabelStatement is a "label" statement, which gives a feature a
+// descriptive name.
+type LabelStatement struct {
+	// The name of the feature, such as "in3".
+	Feature string
+
+	// The label, such as "+12V".
+	Label string
+
+	Position Position
+}
+
+// A ComputeStatement is a "compute" statement, which transforms the raw
+// readings of a feature.  Readings which are not valid, and limits which
+// the device does not provide, are not transformed.
+type ComputeStatement struct {
+	// The name of the feature, such as "in3".
+	Feature string
+
+	// The expression which converts a raw reading to a real value, and its
+	// inverse.
+	From Expr
+	To   Expr
+
+	Position Position
+}
+
+// An IgnoreStatement is an "ignore" statement, which hides a feature.
+type IgnoreStatement struct {
+	// The name of the feature, such as "in3".
+	Feature string
+
+	Position Position
+}
+
+// A SetStatement is a "set" statement, which writes a value to a feature
+// attribute.
+type SetStatement struct {
+	// The name of the attribute, such as "in3_min".
+	Feature string
+
+	// The expression which computes the value to write.
+	Value Expr
+
+	Position Position
+}
+
+// A ConfigError is an error encountered while parsing a configuration
+// file.
+type ConfigError struct {
+	Position Position
+	Message  string
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("lmsensors: %s: %s", e.Position, e.Message)
+}
+
+// ParseConfig parses an lm-sensors configuration from r.  The name of the
+// file is used in the Positions of statements and in errors.
+func ParseConfig(r io.Reader, name string) (*Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	if err := c.parse(string(b), name); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadConfig loads the system lm-sensors configuration in the same order as
+// libsensors: /etc/sensors3.conf, or /etc/sensors.conf if it does not
+// exist, followed by each file in /etc/sensors.d in alphabetical order.
+// Missing files are skipped.
+func LoadConfig() (*Config, error) {
+	return loadConfig(&systemFilesystem{})
+}
+
+// loadConfig loads the system configuration using filesystem fs.
+func loadConfig(fs filesystem) (*Config, error) {
+	var files []string
+	for _, f := range []string{configFile, altConfigFile} {
+		if _, err := fs.Stat(f); err == nil {
+			files = append(files, f)
+			break
+		}
+	}
+
+	if _, err := fs.Stat(configDir); err == nil {
+		var names []string
+		err := fs.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if path == configDir {
+				return nil
+			}
+
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			// libsensors skips hidden files and anything that isn't a
+			// regular file
+			if strings.HasPrefix(filepath.Base(path), ".") || !info.Mode().IsRegular() {
+				return nil
+			}
+
+			names = append(names, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(names)
+		files = append(files, names...)
+	}
+
+	c := &Config{}
+	for _, f := range files {
+		s, err := fs.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.parse(s, f); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
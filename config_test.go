@@ -0,0 +1,239 @@
+package lmsensors
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	const file = "sensors3.conf"
+
+	pos := func(line int) Position {
+		return Position{File: file, Line: line}
+	}
+
+	tests := []struct {
+		name string
+		s    string
+		c    *Config
+		err  string
+	}{
+		{
+			name: "empty",
+			s:    "# nothing to see here\n\n",
+			c:    &Config{},
+		},
+		{
+			name: "full",
+			s: strings.Join([]string{
+				`# Board configuration`,
+				`bus "i2c-0" "SMBus I801 adapter at 0400"`,
+				``,
+				`chip "it87-*" "it8728-*"   # Super I/O`,
+				`    label in3 "+12V"`,
+				`    compute in3 @*(1+120/56), \`,
+				`        @/(1+120/56)`,
+				`    set in3_min 12 * 0.95`,
+				`    ignore in8`,
+				``,
+				`chip "coretemp-isa-0000"`,
+				`    label temp1 "Package\tid 0"`,
+			}, "\n"),
+			c: &Config{
+				Buses: []*BusStatement{{
+					Bus:      "i2c-0",
+					Adapter:  "SMBus I801 adapter at 0400",
+					Position: pos(2),
+				}},
+				Chips: []*ChipBlock{
+					{
+						Names: []string{"it87-*", "it8728-*"},
+						Labels: []*LabelStatement{{
+							Feature:  "in3",
+							Label:    "+12V",
+							Position: pos(5),
+						}},
+						Computes: []*ComputeStatement{{
+							Feature: "in3",
+							From: &binaryExpr{op: '*', x: rawExpr{}, y: &binaryExpr{
+								op: '+',
+								x:  numberExpr(1),
+								y:  &binaryExpr{op: '/', x: numberExpr(120), y: numberExpr(56)},
+							}},
+							To: &binaryExpr{op: '/', x: rawExpr{}, y: &binaryExpr{
+								op: '+',
+								x:  numberExpr(1),
+								y:  &binaryExpr{op: '/', x: numberExpr(120), y: numberExpr(56)},
+							}},
+							Position: pos(6),
+						}},
+						Ignores: []*IgnoreStatement{{
+							Feature:  "in8",
+							Position: pos(9),
+						}},
+						Sets: []*SetStatement{{
+							Feature:  "in3_min",
+							Value:    &binaryExpr{op: '*', x: numberExpr(12), y: numberExpr(0.95)},
+							Position: pos(8),
+						}},
+						Position: pos(4),
+					},
+					{
+						Names: []string{"coretemp-isa-0000"},
+						Labels: []*LabelStatement{{
+							Feature:  "temp1",
+							Label:    "Package\tid 0",
+							Position: pos(12),
+						}},
+						Position: pos(11),
+					},
+				},
+			},
+		},
+		{
+			name: "label before chip",
+			s:    "\nlabel in0 \"Vcore\"\n",
+			err:  "lmsensors: sensors3.conf:2: Label statement before first chip statement",
+		},
+		{
+			name: "invalid keyword",
+			s:    "chip \"it87-*\"\n  lable in0 \"Vcore\"\n",
+			err:  "lmsensors: sensors3.conf:2: invalid keyword 'lable'",
+		},
+		{
+			name: "unquoted chip pattern",
+			s:    "chip it87-*\n",
+			err:  "lmsensors: sensors3.conf:1: syntax error: unexpected '-'",
+		},
+		{
+			name: "invalid chip name",
+			s:    "chip \"it87-*\" \"it87-usb-0\"\n",
+			err:  `lmsensors: sensors3.conf:1: parse error in chip name "it87-usb-0"`,
+		},
+		{
+			name: "unterminated string",
+			s:    "chip \"it87-*\n",
+			err:  "lmsensors: sensors3.conf:1: no matching double quote",
+		},
+		{
+			name: "invalid escape",
+			s:    "chip \"it87\\q\"\n",
+			err:  "lmsensors: sensors3.conf:1: invalid escape sequence '\\q'",
+		},
+		{
+			name: "missing inverse",
+			s:    "chip \"it87-*\"\ncompute in3 @*2\n",
+			err:  "lmsensors: sensors3.conf:2: syntax error: unexpected end of line",
+		},
+		{
+			name: "unbalanced parentheses",
+			s:    "chip \"it87-*\"\n\nset in3_min (1+2\n",
+			err:  "lmsensors: sensors3.conf:3: syntax error: unexpected end of line",
+		},
+		{
+			name: "trailing tokens",
+			s:    "chip \"it87-*\"\nignore in3 in4\n",
+			err:  "lmsensors: sensors3.conf:2: syntax error: unexpected 'in4'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig(strings.NewReader(tt.s), file)
+			if tt.err != "" {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				if want, got := tt.err, err.Error(); want != got {
+					t.Fatalf("unexpected error:\n- want: %s\n-  got: %s", want, got)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.c, c; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Config:\n- want: %#v\n-  got: %#v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigExprPrecedence(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "1 + 2 * 3", want: "(1 + (2 * 3))"},
+		{s: "-@ * 2", want: "((-@) * 2)"},
+		{s: "^`@ / in0", want: "((^(`@)) / in0)"},
+		{s: "(1 - 2) - 3", want: "((1 - 2) - 3)"},
+		{s: ".5 * 10.", want: "(0.5 * 10)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			c, err := ParseConfig(strings.NewReader("chip \"it87-*\"\nset in0_min "+tt.s), "")
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if got := c.Chips[0].Sets[0].Value.String(); tt.want != got {
+				t.Fatalf("unexpected expression:\n- want: %s\n-  got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := &memoryFileInfo{isDir: true}
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{
+				name:     "/etc/sensors3.conf",
+				contents: "chip \"it87-*\"\nlabel in0 \"Vcore\"",
+			},
+			{
+				name: "/etc/sensors.d",
+				info: dir,
+			},
+			{
+				name:     "/etc/sensors.d/20-board.conf",
+				contents: "chip \"it87-*\"\nlabel in0 \"CPU\"",
+			},
+			{
+				name:     "/etc/sensors.d/.hidden.conf",
+				contents: "invalid",
+			},
+			{
+				name:     "/etc/sensors.d/10-coretemp.conf",
+				contents: "chip \"coretemp-*\"\nignore temp2",
+			},
+		},
+	}
+
+	c, err := loadConfig(fs)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	var got []string
+	for _, cb := range c.Chips {
+		got = append(got, cb.Position.String())
+	}
+
+	want := []string{
+		"/etc/sensors3.conf:1",
+		"/etc/sensors.d/10-coretemp.conf:1",
+		"/etc/sensors.d/20-board.conf:1",
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected chip blocks:\n- want: %v\n-  got: %v", want, got)
+	}
+}
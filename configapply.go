@@ -0,0 +1,96 @@
+package lmsensors
+
+// apply applies the label, ignore, and compute statements of all chip
+// blocks which match Device d to its Sensors.  As in libsensors, statements
+// in later chip blocks take precedence over those in earlier ones.
+func (c *Config) apply(d *Device) error {
+	labels := make(map[string]string)
+	ignored := make(map[string]bool)
+	computes := make(map[string]*ComputeStatement)
+
+	for _, cb := range c.Chips {
+		if !cb.matches(d) {
+			continue
+		}
+
+		for _, l := range cb.Labels {
+			labels[l.Feature] = l.Label
+		}
+		for _, i := range cb.Ignores {
+			ignored[i.Feature] = true
+		}
+		for _, cs := range cb.Computes {
+			computes[cs.Feature] = cs
+		}
+	}
+
+	// Ignored features may still be referenced by compute statements, so
+	// readings are transformed before any Sensors are removed
+	if len(computes) > 0 {
+		if err := d.compute(computes); err != nil {
+			return err
+		}
+	}
+
+	sensors := d.Sensors[:0]
+	for _, s := range d.Sensors {
+		if ignored[s.name()] {
+			continue
+		}
+
+		if l, ok := labels[s.name()]; ok {
+			if set, ok := labelField(s); ok {
+				set(l)
+			}
+		}
+
+		sensors = append(sensors, s)
+	}
+
+	d.Sensors = sensors
+	return nil
+}
+
+// matches determines if any chip block in the Config matches Device d.
+func (c *Config) matches(d *Device) bool {
+	for _, cb := range c.Chips {
+		if cb.matches(d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches determines if any of the chip name patterns of the ChipBlock
+// match Device d.
+func (cb *ChipBlock) matches(d *Device) bool {
+	for _, n := range cb.Names {
+		// Patterns are validated when the configuration is parsed
+		c, err := ParseChipName(n)
+		if err == nil && c.Match(d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// labelField returns a function which sets the Label field of Sensor s.  If
+// s does not have a label, labelField returns false.
+func labelField(s Sensor) (func(v string), bool) {
+	switch s := s.(type) {
+	case *CurrentSensor:
+		return func(v string) { s.Label = v }, true
+	case *FanSensor:
+		return func(v string) { s.Label = v }, true
+	case *PowerSensor:
+		return func(v string) { s.Label = v }, true
+	case *TemperatureSensor:
+		return func(v string) { s.Label = v }, true
+	case *VoltageSensor:
+		return func(v string) { s.Label = v }, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,62 @@
+package lmsensors
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigApply(t *testing.T) {
+	const conf = `
+chip "it8728-*"
+    label in0 "Vcore"
+    label in3 "+5V"
+    label fan1 "CPU Fan"
+    ignore in8
+
+# Board-specific overrides
+chip "*-*"
+    label in3 "+12V"
+
+chip "coretemp-*"
+    ignore in0
+
+chip "it8728-isa-0290"
+    ignore fan1
+
+chip "it8728-i2c-*"
+    ignore intrusion0
+`
+
+	c, err := ParseConfig(strings.NewReader(conf), "sensors3.conf")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	d := &Device{
+		Name:       "it8728-00",
+		DevicePath: "/sys/devices/platform/it87.656",
+		Sensors: []Sensor{
+			&FanSensor{Name: "fan1"},
+			&VoltageSensor{Name: "in0", Label: "CPU"},
+			&VoltageSensor{Name: "in3"},
+			&VoltageSensor{Name: "in8"},
+			&IntrusionSensor{Name: "intrusion0"},
+		},
+	}
+
+	if err := c.apply(d); err != nil {
+		t.Fatalf("failed to apply config: %v", err)
+	}
+
+	want := []Sensor{
+		&VoltageSensor{Name: "in0", Label: "Vcore"},
+		&VoltageSensor{Name: "in3", Label: "+12V"},
+		&IntrusionSensor{Name: "intrusion0"},
+	}
+
+	if got := d.Sensors; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Sensors:\n- want: %v\n-  got: %v",
+			devicesStr([]*Device{{Sensors: want}}), devicesStr([]*Device{{Sensors: got}}))
+	}
+}
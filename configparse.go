@@ -0,0 +1,541 @@
+package lmsensors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A tokenKind is the kind of a token in a configuration file.
+type tokenKind int
+
+// Possible tokenKind values.
+const (
+	tokenEOF tokenKind = iota
+	tokenEOL
+	tokenName
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+// A token is a lexical token in a configuration file.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// String returns a description of the token for use in errors.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of file"
+	case tokenEOL:
+		return "end of line"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// A lexer splits a configuration file into tokens, following the lexical
+// rules of libsensors: "#" begins a comment which runs to the end of the
+// line, a backslash at the end of a line continues the line, and strings
+// are double-quoted with C-style escapes.
+type lexer struct {
+	s    string
+	i    int
+	line int
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	for l.i < len(l.s) {
+		c := l.s[l.i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.i++
+		case c == '#':
+			for l.i < len(l.s) && l.s[l.i] != '\n' {
+				l.i++
+			}
+		case c == '\\':
+			// Only a line continuation is permitted outside of strings
+			j := l.i + 1
+			for j < len(l.s) && (l.s[j] == ' ' || l.s[j] == '\t' || l.s[j] == '\r') {
+				j++
+			}
+			if j < len(l.s) && l.s[j] != '\n' {
+				return token{}, fmt.Errorf("invalid character '\\'")
+			}
+
+			l.i = j + 1
+			l.line++
+		case c == '\n':
+			t := token{kind: tokenEOL, line: l.line}
+			l.i++
+			l.line++
+			return t, nil
+		case c == '"':
+			return l.string()
+		case isDigit(c) || (c == '.' && l.i+1 < len(l.s) && isDigit(l.s[l.i+1])):
+			return l.number(), nil
+		case isNameStart(c):
+			start := l.i
+			for l.i < len(l.s) && isNameChar(l.s[l.i]) {
+				l.i++
+			}
+
+			return token{kind: tokenName, text: l.s[start:l.i], line: l.line}, nil
+		case strings.IndexByte(",+-*/^`@()", c) != -1:
+			l.i++
+			return token{kind: tokenPunct, text: string(c), line: l.line}, nil
+		default:
+			return token{}, fmt.Errorf("invalid character %q", c)
+		}
+	}
+
+	return token{kind: tokenEOF, line: l.line}, nil
+}
+
+// string lexes a double-quoted string.
+func (l *lexer) string() (token, error) {
+	t := token{kind: tokenString, line: l.line}
+
+	var b strings.Builder
+	for l.i++; l.i < len(l.s); l.i++ {
+		c := l.s[l.i]
+		switch c {
+		case '"':
+			l.i++
+			t.text = b.String()
+			return t, nil
+		case '\n':
+			return token{}, fmt.Errorf("no matching double quote")
+		case '\\':
+			l.i++
+			if l.i == len(l.s) {
+				return token{}, fmt.Errorf("no matching double quote")
+			}
+
+			e, ok := escapes[l.s[l.i]]
+			if !ok {
+				return token{}, fmt.Errorf("invalid escape sequence '\\%c'", l.s[l.i])
+			}
+
+			b.WriteByte(e)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return token{}, fmt.Errorf("no matching double quote")
+}
+
+// number lexes a decimal number.
+func (l *lexer) number() token {
+	start := l.i
+	for l.i < len(l.s) && isDigit(l.s[l.i]) {
+		l.i++
+	}
+
+	if l.i < len(l.s) && l.s[l.i] == '.' {
+		l.i++
+		for l.i < len(l.s) && isDigit(l.s[l.i]) {
+			l.i++
+		}
+	}
+
+	return token{kind: tokenNumber, text: l.s[start:l.i], line: l.line}
+}
+
+// escapes maps the escape sequences permitted in strings to the characters
+// they represent.
+var escapes = map[byte]byte{
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+	'\\': '\\',
+	'"':  '"',
+}
+
+func isDigit(c byte) bool     { return c >= '0' && c <= '9' }
+func isNameStart(c byte) bool { return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isNameChar(c byte) bool  { return isNameStart(c) || isDigit(c) }
+
+// A parser parses the statements of a single configuration file.
+type parser struct {
+	l    *lexer
+	tok  token
+	file string
+
+	c *Config
+
+	// The chip block to which statements are added.
+	chip *ChipBlock
+}
+
+// parse parses the configuration file s, named file, appending its
+// statements to c.
+func (c *Config) parse(s, file string) error {
+	p := &parser{
+		l:    &lexer{s: s, line: 1},
+		file: file,
+		c:    c,
+	}
+
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	for p.tok.kind != tokenEOF {
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	line := p.l.line
+
+	t, err := p.l.next()
+	if err != nil {
+		return p.errorf(line, "%v", err)
+	}
+
+	p.tok = t
+	return nil
+}
+
+// statement parses a single statement and the end of its line.
+func (p *parser) statement() error {
+	if p.tok.kind == tokenEOL {
+		return p.advance()
+	}
+
+	kw := p.tok
+	if kw.kind != tokenName {
+		return p.unexpected()
+	}
+
+	pos := Position{File: p.file, Line: kw.line}
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	var err error
+	switch kw.text {
+	case "bus":
+		err = p.bus(pos)
+	case "chip":
+		err = p.chipStatement(pos)
+	case "label", "compute", "ignore", "set":
+		if p.chip == nil {
+			return p.errorf(kw.line, "%s%s statement before first chip statement",
+				strings.ToUpper(kw.text[:1]), kw.text[1:])
+		}
+
+		switch kw.text {
+		case "label":
+			err = p.label(pos)
+		case "compute":
+			err = p.compute(pos)
+		case "ignore":
+			err = p.ignore(pos)
+		case "set":
+			err = p.set(pos)
+		}
+	default:
+		return p.errorf(kw.line, "invalid keyword '%s'", kw.text)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch p.tok.kind {
+	case tokenEOL:
+		return p.advance()
+	case tokenEOF:
+		return nil
+	default:
+		return p.unexpected()
+	}
+}
+
+// bus parses the arguments of a "bus" statement.
+func (p *parser) bus(pos Position) error {
+	bus, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	adapter, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	p.c.Buses = append(p.c.Buses, &BusStatement{
+		Bus:      bus,
+		Adapter:  adapter,
+		Position: pos,
+	})
+
+	return nil
+}
+
+// chipStatement parses the arguments of a "chip" statement, and begins a
+// new chip block.
+func (p *parser) chipStatement(pos Position) error {
+	cb := &ChipBlock{Position: pos}
+	for p.tok.kind == tokenName || p.tok.kind == tokenString {
+		if _, err := ParseChipName(p.tok.text); err != nil {
+			return p.errorf(p.tok.line, "parse error in chip name %q", p.tok.text)
+		}
+
+		cb.Names = append(cb.Names, p.tok.text)
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	if len(cb.Names) == 0 {
+		return p.unexpected()
+	}
+
+	p.c.Chips = append(p.c.Chips, cb)
+	p.chip = cb
+	return nil
+}
+
+// label parses the arguments of a "label" statement.
+func (p *parser) label(pos Position) error {
+	feature, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	label, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	p.chip.Labels = append(p.chip.Labels, &LabelStatement{
+		Feature:  feature,
+		Label:    label,
+		Position: pos,
+	})
+
+	return nil
+}
+
+// compute parses the arguments of a "compute" statement.
+func (p *parser) compute(pos Position) error {
+	feature, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	from, err := p.expr()
+	if err != nil {
+		return err
+	}
+
+	if !p.isPunct(",") {
+		return p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	to, err := p.expr()
+	if err != nil {
+		return err
+	}
+
+	p.chip.Computes = append(p.chip.Computes, &ComputeStatement{
+		Feature:  feature,
+		From:     from,
+		To:       to,
+		Position: pos,
+	})
+
+	return nil
+}
+
+// ignore parses the arguments of an "ignore" statement.
+func (p *parser) ignore(pos Position) error {
+	feature, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	p.chip.Ignores = append(p.chip.Ignores, &IgnoreStatement{
+		Feature:  feature,
+		Position: pos,
+	})
+
+	return nil
+}
+
+// set parses the arguments of a "set" statement.
+func (p *parser) set(pos Position) error {
+	feature, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	v, err := p.expr()
+	if err != nil {
+		return err
+	}
+
+	p.chip.Sets = append(p.chip.Sets, &SetStatement{
+		Feature:  feature,
+		Value:    v,
+		Position: pos,
+	})
+
+	return nil
+}
+
+// name parses a bare or quoted name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName && p.tok.kind != tokenString {
+		return "", p.unexpected()
+	}
+
+	s := p.tok.text
+	return s, p.advance()
+}
+
+// expr parses an expression.  Operators follow the precedence used by
+// libsensors: the prefix operators "-", "^", and "`" bind most tightly,
+// followed by "*" and "/", and then "+" and "-".
+func (p *parser) expr() (Expr, error) {
+	x, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct("+") || p.isPunct("-") {
+		op := p.tok.text[0]
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		y, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		x = &binaryExpr{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+// term parses a product or quotient.
+func (p *parser) term() (Expr, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct("*") || p.isPunct("/") {
+		op := p.tok.text[0]
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		x = &binaryExpr{op: op, x: x, y: y}
+	}
+
+	return x, nil
+}
+
+// unary parses a prefix operator and its operand, or a primary expression.
+func (p *parser) unary() (Expr, error) {
+	if p.isPunct("-") || p.isPunct("^") || p.isPunct("`") {
+		op := p.tok.text[0]
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryExpr{op: op, x: x}, nil
+	}
+
+	return p.primary()
+}
+
+// primary parses a number, feature reference, "@", or parenthesized
+// expression.
+func (p *parser) primary() (Expr, error) {
+	t := p.tok
+	switch {
+	case t.kind == tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t.line, "invalid number '%s'", t.text)
+		}
+
+		return numberExpr(f), p.advance()
+	case t.kind == tokenName:
+		return featureExpr(t.text), p.advance()
+	case p.isPunct("@"):
+		return rawExpr{}, p.advance()
+	case p.isPunct("("):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isPunct(")") {
+			return nil, p.unexpected()
+		}
+
+		return x, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
+
+// isPunct reports whether the current token is the punctuation s.
+func (p *parser) isPunct(s string) bool {
+	return p.tok.kind == tokenPunct && p.tok.text == s
+}
+
+// unexpected returns a syntax error for the current token.
+func (p *parser) unexpected() error {
+	return p.errorf(p.tok.line, "syntax error: unexpected %s", p.tok)
+}
+
+// errorf returns a *ConfigError at the specified line.
+func (p *parser) errorf(line int, format string, v ...interface{}) error {
+	return &ConfigError{
+		Position: Position{File: p.file, Line: line},
+		Message:  fmt.Sprintf(format, v...),
+	}
+}
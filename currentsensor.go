@@ -26,10 +26,25 @@ type CurrentSensor struct {
 
 	// The critical current threshold, in Amperes, indicated by the sensor.
 	Critical float64
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
+
+	// The optional attributes provided by the sensor's device, such as "crit"
+	// or "alarm", keyed by attribute name.  Optional attributes which are
+	// present are reported even if their values are zero.  If nil, limits are
+	// only reported if their values are nonzero.
+	Attributes map[string]bool
 }
 
-func (s *CurrentSensor) name() string        { return s.Name }
-func (s *CurrentSensor) setName(name string) { s.Name = name }
+func (s *CurrentSensor) name() string                      { return s.Name }
+func (s *CurrentSensor) setName(name string)               { s.Name = name }
+func (s *CurrentSensor) validity() map[string]Validity     { return s.Validity }
+func (s *CurrentSensor) setValidity(v map[string]Validity) { s.Validity = v }
+func (s *CurrentSensor) attributes() map[string]bool       { return s.Attributes }
+func (s *CurrentSensor) setAttributes(a map[string]bool)   { s.Attributes = a }
 
 func (s *CurrentSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
@@ -51,8 +66,13 @@ func (s *CurrentSensor) parse(raw map[string]string) error {
 			case "max":
 				s.Maximum = f
 			}
+
+			if k != "input" {
+				setPresent(s, k)
+			}
 		case "alarm":
 			s.Alarm = v != "0"
+			setPresent(s, k)
 		case "label":
 			s.Label = v
 		}
@@ -0,0 +1,433 @@
+package lmsensors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// A CurvePoint maps a temperature in degrees Celsius to a PWM duty cycle.
+type CurvePoint struct {
+	Temperature float64
+	Duty        int
+}
+
+// A Curve is a piecewise-linear mapping of temperatures to PWM duty cycles.
+// Temperatures below the first point or above the last point map to the
+// duty cycle of that point.
+type Curve []CurvePoint
+
+// Duty returns the duty cycle for temperature temp.
+func (c Curve) Duty(temp float64) int {
+	if len(c) == 0 {
+		return 255
+	}
+
+	if temp <= c[0].Temperature {
+		return c[0].Duty
+	}
+
+	for i := 1; i < len(c); i++ {
+		lo, hi := c[i-1], c[i]
+		if temp > hi.Temperature {
+			continue
+		}
+
+		// Interpolate between the points which surround temp
+		f := (temp - lo.Temperature) / (hi.Temperature - lo.Temperature)
+		return lo.Duty + int(f*float64(hi.Duty-lo.Duty)+0.5)
+	}
+
+	return c[len(c)-1].Duty
+}
+
+// An Aggregate is a method of combining the temperatures of several
+// CurveSources into a single temperature.
+type Aggregate int
+
+// All possible Aggregate constants.
+const (
+	// Use the highest temperature.
+	AggregateMax Aggregate = 0
+
+	// Use the average temperature, weighted by each CurveSource's Weight.
+	AggregateWeightedAverage Aggregate = 1
+)
+
+// A CurveSource is a TemperatureSensor used as an input to a FanCurve.
+type CurveSource struct {
+	// The hwmon directory of the sensor's Device, such as "hwmon1".
+	Hwmon string
+
+	// The name of the TemperatureSensor, such as "temp1".
+	Sensor string
+
+	// The weight of the sensor when using AggregateWeightedAverage.  If
+	// zero, a weight of 1 is used.
+	Weight float64
+}
+
+// A FanCurve describes how a PWM output is driven in response to one or
+// more temperatures.
+//
+// If any source temperature cannot be read or is faulted, the output is
+// run at full speed until all sources are valid again.
+type FanCurve struct {
+	// The hwmon directory of the output's Device, such as "hwmon1".
+	Hwmon string
+
+	// The name of the PWMSensor, such as "pwm1".
+	PWM string
+
+	// The temperature inputs, and the method used to combine them.
+	Sources   []CurveSource
+	Aggregate Aggregate
+
+	// The mapping of temperatures to duty cycles.  Curve must contain at
+	// least one point, and its points must be in order of increasing
+	// temperature.
+	Curve Curve
+
+	// The number of degrees Celsius by which the temperature must fall
+	// before the duty cycle is lowered.  Rising temperatures take effect
+	// immediately.
+	Hysteresis float64
+
+	// The maximum change in duty cycle per second.  If zero, changes are
+	// applied immediately.  Changes to full speed due to invalid sources or
+	// critical temperatures are never limited.
+	RampRate float64
+
+	// The lowest duty cycle which is ever applied.
+	MinDuty int
+
+	// If non-zero, the temperature at or above which the duty cycle is
+	// never lower than CriticalDuty.
+	Critical float64
+
+	// The lowest duty cycle applied at or above the Critical temperature.
+	// If zero, full speed is used.
+	CriticalDuty int
+}
+
+// A CurveController drives PWM outputs according to FanCurves.
+type CurveController struct {
+	s      *Scanner
+	curves []*curveState
+}
+
+// A curveState is the running state of a FanCurve.
+type curveState struct {
+	fc   *FanCurve
+	ctrl *PWMControl
+
+	// Whether or not a duty cycle has been applied yet.
+	started bool
+
+	// The temperature used to compute the current duty cycle, the duty
+	// cycle itself, and the time at which it was applied.
+	temp float64
+	duty int
+	last time.Time
+}
+
+// ControlCurves takes manual control of the PWM outputs described by curves,
+// and returns a CurveController which drives them.  Call Update or Run to
+// apply the curves, and Close to return the outputs to their original
+// control mode.
+//
+// Each PWM output may only be driven by one curve.  Devices which cannot be
+// scanned are ignored, unless they are used by curves.
+func (s *Scanner) ControlCurves(curves ...*FanCurve) (*CurveController, error) {
+	outputs := make(map[string]bool, len(curves))
+	for _, fc := range curves {
+		if err := fc.validate(); err != nil {
+			return nil, err
+		}
+
+		// Curves for the same output would fight over its duty cycle
+		out := fc.Hwmon + "/" + fc.PWM
+		if outputs[out] {
+			return nil, fmt.Errorf("lmsensors: multiple fan curves for PWM output %s", out)
+		}
+		outputs[out] = true
+	}
+
+	devices, err := s.scanCurves(curves)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CurveController{s: s}
+	for _, fc := range curves {
+		d, ps := findSensor(devices, fc.Hwmon, fc.PWM)
+		pwm, ok := ps.(*PWMSensor)
+		if !ok {
+			_ = c.Close()
+			return nil, fmt.Errorf("lmsensors: PWM output %s/%s not found", fc.Hwmon, fc.PWM)
+		}
+
+		ctrl, err := d.ControlPWM(pwm)
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+
+		c.curves = append(c.curves, &curveState{
+			fc:   fc,
+			ctrl: ctrl,
+		})
+	}
+
+	return c, nil
+}
+
+// Run calls Update every interval until ctx is canceled, or Update returns
+// an error.
+//
+// If Update returns an error, all PWM outputs are set to full speed before
+// Run returns, so fans are never left running at a duty cycle which may no
+// longer be safe.  The CurveController cannot be used once this happens.
+func (c *CurveController) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if err := c.Update(); err != nil {
+			c.fullSpeed()
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.s.after(interval):
+		}
+	}
+}
+
+// Update reads all source temperatures and applies a new duty cycle to each
+// PWM output.  Update only returns an error for Devices which cannot be
+// scanned if they are used by the curves.
+func (c *CurveController) Update() error {
+	curves := make([]*FanCurve, 0, len(c.curves))
+	for _, cs := range c.curves {
+		curves = append(curves, cs.fc)
+	}
+
+	devices, err := c.s.scanCurves(curves)
+	if err != nil {
+		return err
+	}
+
+	now := c.s.now()
+	for _, cs := range c.curves {
+		duty := cs.next(devices, now)
+
+		if _, err := cs.ctrl.Set(duty); err != nil {
+			return err
+		}
+
+		cs.duty = duty
+		cs.last = now
+	}
+
+	return nil
+}
+
+// Close returns all PWM outputs to their original control mode and duty
+// cycle.
+func (c *CurveController) Close() error {
+	var err error
+	for _, cs := range c.curves {
+		if cerr := cs.ctrl.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// fullSpeed ends manual control of all PWM outputs by running them at full
+// speed.  Errors are ignored, so every output is attempted.
+func (c *CurveController) fullSpeed() {
+	for _, cs := range c.curves {
+		_ = cs.ctrl.FullSpeed()
+	}
+}
+
+// next computes the next duty cycle for a curve at time now.
+func (cs *curveState) next(devices []*Device, now time.Time) int {
+	fc := cs.fc
+
+	temp, ok := fc.temperature(devices)
+	if !ok {
+		// Fail safe, and forget the hysteresis state so the curve is
+		// reapplied from scratch once the sources are valid again
+		cs.temp = 0
+		cs.started = false
+		return 255
+	}
+
+	// Only lower the duty cycle once the temperature has dropped by more
+	// than the hysteresis
+	if cs.started && temp < cs.temp && cs.temp-temp < fc.Hysteresis {
+		temp = cs.temp
+	}
+	cs.temp = temp
+
+	duty := fc.Curve.Duty(temp)
+
+	if cs.started && fc.RampRate > 0 {
+		// Always allow some change, even if updates are very frequent
+		step := int(math.Ceil(fc.RampRate * now.Sub(cs.last).Seconds()))
+		switch {
+		case duty > cs.duty+step:
+			duty = cs.duty + step
+		case duty < cs.duty-step:
+			duty = cs.duty - step
+		}
+	}
+
+	if duty < fc.MinDuty {
+		duty = fc.MinDuty
+	}
+
+	if fc.Critical != 0 && temp >= fc.Critical {
+		floor := fc.CriticalDuty
+		if floor == 0 {
+			floor = 255
+		}
+
+		if duty < floor {
+			duty = floor
+		}
+	}
+
+	switch {
+	case duty < 0:
+		duty = 0
+	case duty > 255:
+		duty = 255
+	}
+
+	cs.started = true
+	return duty
+}
+
+// temperature computes the aggregate temperature of the FanCurve's sources.
+// If any source cannot be found or is not valid, temperature returns false.
+func (fc *FanCurve) temperature(devices []*Device) (float64, bool) {
+	var hottest, sum, weights float64
+	for i, src := range fc.Sources {
+		_, s := findSensor(devices, src.Hwmon, src.Sensor)
+		ts, ok := s.(*TemperatureSensor)
+		if !ok || !ts.Validity["input"].OK() {
+			return 0, false
+		}
+
+		if i == 0 || ts.Input > hottest {
+			hottest = ts.Input
+		}
+
+		w := src.Weight
+		if w == 0 {
+			w = 1
+		}
+
+		sum += w * ts.Input
+		weights += w
+	}
+
+	if fc.Aggregate == AggregateWeightedAverage {
+		return sum / weights, true
+	}
+
+	return hottest, true
+}
+
+// validate verifies that a FanCurve is well-formed.
+func (fc *FanCurve) validate() error {
+	if len(fc.Sources) == 0 {
+		return fmt.Errorf("lmsensors: fan curve for %s/%s has no sources", fc.Hwmon, fc.PWM)
+	}
+
+	if len(fc.Curve) == 0 {
+		return fmt.Errorf("lmsensors: fan curve for %s/%s has no points", fc.Hwmon, fc.PWM)
+	}
+
+	ok := sort.SliceIsSorted(fc.Curve, func(i, j int) bool {
+		return fc.Curve[i].Temperature < fc.Curve[j].Temperature
+	})
+	if !ok {
+		return fmt.Errorf("lmsensors: fan curve for %s/%s is not in order of increasing temperature", fc.Hwmon, fc.PWM)
+	}
+
+	for _, p := range fc.Curve {
+		if p.Duty < 0 || p.Duty > 255 {
+			return fmt.Errorf("lmsensors: fan curve for %s/%s has duty cycle %d out of range 0-255",
+				fc.Hwmon, fc.PWM, p.Duty)
+		}
+	}
+
+	return nil
+}
+
+// uses reports whether the FanCurve uses the Device with the specified
+// hwmon directory, for its output or any of its sources.
+func (fc *FanCurve) uses(hwmon string) bool {
+	if fc.Hwmon == hwmon {
+		return true
+	}
+
+	for _, src := range fc.Sources {
+		if src.Hwmon == hwmon {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scanCurves scans for Devices, as Scan does, but only returns an error for
+// a Device which cannot be scanned if it is used by curves, as fancontrol
+// only reads the devices it controls.
+func (s *Scanner) scanCurves(curves []*FanCurve) ([]*Device, error) {
+	results, err := s.ScanResults()
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*Device, 0, len(results))
+	for _, r := range results {
+		if r.Err == nil {
+			devices = append(devices, r.Device)
+			continue
+		}
+
+		for _, fc := range curves {
+			if fc.uses(r.Device.Hwmon) {
+				return nil, r.Err
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// findSensor finds the Sensor with the specified name on the Device with
+// the specified hwmon directory.
+func findSensor(devices []*Device, hwmon, name string) (*Device, Sensor) {
+	for _, d := range devices {
+		if d.Hwmon != hwmon {
+			continue
+		}
+
+		for _, s := range d.Sensors {
+			if s.name() == name {
+				return d, s
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,427 @@
+package lmsensors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCurveDuty(t *testing.T) {
+	c := Curve{
+		{Temperature: 30, Duty: 50},
+		{Temperature: 50, Duty: 100},
+		{Temperature: 70, Duty: 255},
+	}
+
+	tests := []struct {
+		temp float64
+		duty int
+	}{
+		{temp: 0, duty: 50},
+		{temp: 30, duty: 50},
+		{temp: 40, duty: 75},
+		{temp: 50, duty: 100},
+		{temp: 55, duty: 139},
+		{temp: 70, duty: 255},
+		{temp: 100, duty: 255},
+	}
+
+	for _, tt := range tests {
+		if want, got := tt.duty, c.Duty(tt.temp); want != got {
+			t.Fatalf("unexpected duty cycle at %v degrees: %d != %d", tt.temp, want, got)
+		}
+	}
+}
+
+func TestFanCurveTemperature(t *testing.T) {
+	devices := []*Device{{
+		Hwmon: "hwmon1",
+		Sensors: []Sensor{
+			&TemperatureSensor{Name: "temp1", Input: 40},
+			&TemperatureSensor{Name: "temp2", Input: 70},
+			&TemperatureSensor{
+				Name:     "temp3",
+				Input:    -127,
+				Validity: map[string]Validity{"input": {State: StateFaulted}},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		fc   *FanCurve
+		temp float64
+		ok   bool
+	}{
+		{
+			name: "max",
+			fc: &FanCurve{
+				Sources: []CurveSource{
+					{Hwmon: "hwmon1", Sensor: "temp1"},
+					{Hwmon: "hwmon1", Sensor: "temp2"},
+				},
+			},
+			temp: 70,
+			ok:   true,
+		},
+		{
+			name: "weighted average",
+			fc: &FanCurve{
+				Sources: []CurveSource{
+					{Hwmon: "hwmon1", Sensor: "temp1", Weight: 2},
+					{Hwmon: "hwmon1", Sensor: "temp2"},
+				},
+				Aggregate: AggregateWeightedAverage,
+			},
+			temp: 50,
+			ok:   true,
+		},
+		{
+			name: "faulted",
+			fc: &FanCurve{
+				Sources: []CurveSource{
+					{Hwmon: "hwmon1", Sensor: "temp1"},
+					{Hwmon: "hwmon1", Sensor: "temp3"},
+				},
+			},
+		},
+		{
+			name: "not found",
+			fc: &FanCurve{
+				Sources: []CurveSource{
+					{Hwmon: "hwmon2", Sensor: "temp1"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			temp, ok := tt.fc.temperature(devices)
+			if temp != tt.temp || ok != tt.ok {
+				t.Fatalf("unexpected temperature:\n- want: %v, %v\n-  got: %v, %v",
+					tt.temp, tt.ok, temp, ok)
+			}
+		})
+	}
+}
+
+func TestCurveController(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.656/hwmon/hwmon1"
+
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.656/hwmon/hwmon1",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{name: dir + "/name", contents: "it8728"},
+			{name: dir + "/pwm1", contents: "128"},
+			{name: dir + "/pwm1_enable", contents: "2"},
+			{name: dir + "/temp1_input", contents: "40000"},
+			{name: dir + "/temp2_input", contents: "35000"},
+		},
+	}
+
+	clock := &fakeClock{
+		now: time.Unix(0, 0),
+	}
+
+	s := &Scanner{
+		fs:    fs,
+		clock: clock,
+	}
+
+	c, err := s.ControlCurves(&FanCurve{
+		Hwmon: "hwmon1",
+		PWM:   "pwm1",
+		Sources: []CurveSource{
+			{Hwmon: "hwmon1", Sensor: "temp1"},
+			{Hwmon: "hwmon1", Sensor: "temp2"},
+		},
+		Curve: Curve{
+			{Temperature: 30, Duty: 50},
+			{Temperature: 70, Duty: 180},
+		},
+		Hysteresis:   3,
+		RampRate:     10,
+		MinDuty:      60,
+		Critical:     80,
+		CriticalDuty: 200,
+	})
+	if err != nil {
+		t.Fatalf("failed to control curves: %v", err)
+	}
+
+	if want, got := "1", fs.contents(dir+"/pwm1_enable"); want != got {
+		t.Fatalf("unexpected pwm1_enable: %q != %q", want, got)
+	}
+
+	tests := []struct {
+		name  string
+		temp1 string
+		temp2 string
+		err   error
+		duty  string
+	}{
+		{
+			name:  "initial",
+			temp1: "40000",
+			temp2: "35000",
+			duty:  "83",
+		},
+		{
+			name:  "within hysteresis",
+			temp1: "38000",
+			temp2: "35000",
+			duty:  "83",
+		},
+		{
+			name:  "ramp limited",
+			temp1: "60000",
+			temp2: "35000",
+			duty:  "93",
+		},
+		{
+			name:  "unreadable source",
+			temp1: "60000",
+			temp2: "35000",
+			err:   &os.PathError{Op: "read", Path: dir + "/temp1_input", Err: syscall.EIO},
+			duty:  "255",
+		},
+		{
+			name:  "minimum duty",
+			temp1: "20000",
+			temp2: "20000",
+			duty:  "60",
+		},
+		{
+			name:  "critical floor",
+			temp1: "20000",
+			temp2: "85000",
+			duty:  "200",
+		},
+	}
+
+	for _, tt := range tests {
+		fs.setContents(dir+"/temp1_input", tt.temp1)
+		fs.setContents(dir+"/temp2_input", tt.temp2)
+		fs.files[6].err = tt.err
+
+		if err := c.Update(); err != nil {
+			t.Fatalf("%s: failed to update: %v", tt.name, err)
+		}
+
+		if want, got := tt.duty, fs.contents(dir+"/pwm1"); want != got {
+			t.Fatalf("%s: unexpected duty cycle: %q != %q", tt.name, want, got)
+		}
+
+		clock.now = clock.now.Add(1 * time.Second)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if want, got := "128", fs.contents(dir+"/pwm1"); want != got {
+		t.Fatalf("unexpected restored pwm1: %q != %q", want, got)
+	}
+	if want, got := "2", fs.contents(dir+"/pwm1_enable"); want != got {
+		t.Fatalf("unexpected restored pwm1_enable: %q != %q", want, got)
+	}
+}
+
+func TestCurveControllerRunFailSafe(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.656/hwmon/hwmon1"
+
+	tests := []struct {
+		name string
+		fail func(fs *memoryFilesystem)
+	}{
+		{
+			name: "scan failure",
+			fail: func(fs *memoryFilesystem) {
+				fs.files[2].err = &os.PathError{Op: "stat", Path: dir, Err: syscall.EIO}
+			},
+		},
+		{
+			name: "set failure",
+			fail: func(fs *memoryFilesystem) {
+				fs.files[4].writeErr = &os.PathError{Op: "write", Path: dir + "/pwm1", Err: syscall.EIO}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &memoryFilesystem{
+				symlinks: map[string]string{
+					"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.656/hwmon/hwmon1",
+				},
+				files: []memoryFile{
+					{
+						name: "/sys/class/hwmon",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name: "/sys/class/hwmon/hwmon1",
+						info: &memoryFileInfo{
+							mode: os.ModeSymlink,
+						},
+					},
+					{
+						name: dir,
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{name: dir + "/name", contents: "it8728"},
+					{name: dir + "/pwm1", contents: "128"},
+					{name: dir + "/pwm1_enable", contents: "2"},
+					{name: dir + "/temp1_input", contents: "40000"},
+				},
+			}
+
+			// The Scanner has no clock, as if it were not created by New
+			s := &Scanner{fs: fs}
+
+			c, err := s.ControlCurves(&FanCurve{
+				Hwmon:   "hwmon1",
+				PWM:     "pwm1",
+				Sources: []CurveSource{{Hwmon: "hwmon1", Sensor: "temp1"}},
+				Curve: Curve{
+					{Temperature: 30, Duty: 50},
+					{Temperature: 70, Duty: 180},
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to control curves: %v", err)
+			}
+
+			tt.fail(fs)
+
+			if err := c.Run(context.Background(), time.Second); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := "0", fs.contents(dir+"/pwm1_enable"); want != got {
+				t.Fatalf("output was not set to full speed: pwm1_enable %q != %q", want, got)
+			}
+		})
+	}
+}
+
+func TestCurveControllerUnusedDeviceError(t *testing.T) {
+	const (
+		dir   = "/sys/devices/platform/it87.656/hwmon/hwmon1"
+		other = "/sys/devices/platform/nct6775.656/hwmon/hwmon2"
+	)
+
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.656/hwmon/hwmon1",
+			"/sys/class/hwmon/hwmon2": "../../devices/platform/nct6775.656/hwmon/hwmon2",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon2",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{name: dir + "/name", contents: "it8728"},
+			{name: dir + "/pwm1", contents: "128"},
+			{name: dir + "/pwm1_enable", contents: "2"},
+			{name: dir + "/temp1_input", contents: "70000"},
+			{
+				name: other,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+				err: &os.PathError{Op: "stat", Path: other, Err: syscall.EIO},
+			},
+			{name: other + "/name", contents: "nct6775"},
+		},
+	}
+
+	s := &Scanner{fs: fs}
+
+	c, err := s.ControlCurves(&FanCurve{
+		Hwmon:   "hwmon1",
+		PWM:     "pwm1",
+		Sources: []CurveSource{{Hwmon: "hwmon1", Sensor: "temp1"}},
+		Curve: Curve{
+			{Temperature: 30, Duty: 50},
+			{Temperature: 70, Duty: 180},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to control curves: %v", err)
+	}
+	defer c.Close()
+
+	// hwmon2 cannot be scanned, but no curve uses it
+	if err := c.Update(); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+
+	if want, got := "180", fs.contents(dir+"/pwm1"); want != got {
+		t.Fatalf("unexpected duty cycle: %q != %q", want, got)
+	}
+}
+
+func TestControlCurvesDuplicateOutput(t *testing.T) {
+	fc := func() *FanCurve {
+		return &FanCurve{
+			Hwmon:   "hwmon1",
+			PWM:     "pwm1",
+			Sources: []CurveSource{{Hwmon: "hwmon1", Sensor: "temp1"}},
+			Curve:   Curve{{Temperature: 30, Duty: 50}},
+		}
+	}
+
+	_, err := (&Scanner{}).ControlCurves(fc(), fc())
+	if want, got := "lmsensors: multiple fan curves for PWM output hwmon1/pwm1", fmt.Sprint(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
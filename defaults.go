@@ -0,0 +1,81 @@
+package lmsensors
+
+import (
+	"strings"
+)
+
+// DefaultConfig is the built-in database of chip defaults used by a Scanner
+// when its Defaults field is nil.  It labels and scales the inputs of
+// common Super I/O chips, following the wiring recommended by their
+// datasheets and the defaults shipped with lm-sensors.
+var DefaultConfig = mustParseConfig(defaultConfig, "<built-in>")
+
+// defaultConfig is the source of DefaultConfig.
+const defaultConfig = `
+# ITE IT8712F and compatible chips use an 8-bit ADC with a 4.096V range, so
+# inputs above that range are wired through resistor dividers.
+chip "it8712-*" "it8716-*" "it8718-*" "it8720-*" "it8726-*"
+
+    label in0 "Vcore"
+    label in1 "VDDR"
+    label in2 "+3.3V"
+    label in3 "+5V"
+    label in4 "+12V"
+    label in5 "-12V"
+    label in6 "-5V"
+    label in7 "5VSB"
+    label in8 "Vbat"
+
+    compute in3 ((6.8/10)+1)*@, @/((6.8/10)+1)
+    compute in4 ((30/10)+1)*@, @/((30/10)+1)
+    compute in5 (1+232/56)*@ - 4.096*232/56, (@ + 4.096*232/56)/(1+232/56)
+    compute in6 (1+120/56)*@ - 4.096*120/56, (@ + 4.096*120/56)/(1+120/56)
+    compute in7 ((6.8/10)+1)*@, @/((6.8/10)+1)
+
+# The negative rails are rarely connected on modern boards.
+chip "it8716-*" "it8718-*" "it8720-*" "it8726-*"
+
+    ignore in5
+    ignore in6
+
+# Winbond and Nuvoton chips scale their internal rails in the driver, so
+# only labels are needed.
+chip "w83627ehf-*" "w83627dhg-*" "w83667hg-*" "nct6775-*" "nct6776-*"
+
+    label in0 "Vcore"
+    label in2 "AVCC"
+    label in3 "+3.3V"
+    label in7 "3VSB"
+    label in8 "Vbat"
+
+chip "nct6779-*" "nct6791-*" "nct6792-*" "nct6793-*" "nct6795-*" "nct6796-*" "nct6797-*" "nct6798-*"
+
+    label in0 "Vcore"
+    label in2 "AVCC"
+    label in3 "+3.3V"
+    label in7 "3VSB"
+    label in8 "Vbat"
+
+chip "w83627thf-*"
+
+    label in0 "Vcore"
+    label in2 "+3.3V"
+    label in3 "+5V"
+    label in4 "+12V"
+    label in7 "5VSB"
+    label in8 "Vbat"
+
+    compute in3 ((34/51)+1)*@, @/((34/51)+1)
+    compute in4 ((28/10)+1)*@, @/((28/10)+1)
+    compute in7 ((34/51)+1)*@, @/((34/51)+1)
+`
+
+// mustParseConfig parses the configuration s, panicking on error.
+func mustParseConfig(s, name string) *Config {
+	c, err := ParseConfig(strings.NewReader(s), name)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
@@ -0,0 +1,144 @@
+package lmsensors
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestScannerDefaults(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.656/hwmon/hwmon1"
+
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.656/hwmon/hwmon1",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{name: dir + "/name", contents: "it8718"},
+			{name: dir + "/in0_input", contents: "1200"},
+			{name: dir + "/in4_input", contents: "3008"},
+			{name: dir + "/in5_input", contents: "1024"},
+		},
+	}
+
+	userConfig := mustParseConfig(`chip "it8718-*"
+    label in0 "CPU"`, "sensors3.conf")
+
+	otherConfig := mustParseConfig(`chip "nct6775-*"
+    label in0 "CPU"`, "sensors3.conf")
+
+	tests := []struct {
+		name       string
+		config     *Config
+		defaults   *Config
+		noDefaults bool
+		in0        string
+		in4        float64
+		in5        bool
+	}{
+		{
+			name: "built-in defaults",
+			in0:  "Vcore",
+			in4:  3.008 * 4,
+		},
+		{
+			name:     "custom defaults",
+			defaults: otherConfig,
+			in4:      3.008,
+			in5:      true,
+		},
+		{
+			name:       "defaults disabled",
+			noDefaults: true,
+			in4:        3.008,
+			in5:        true,
+		},
+		{
+			name:   "user config for chip",
+			config: userConfig,
+			in0:    "CPU",
+			in4:    3.008,
+			in5:    true,
+		},
+		{
+			name:   "user config for other chip",
+			config: otherConfig,
+			in0:    "Vcore",
+			in4:    3.008 * 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{
+				Config:     tt.config,
+				Defaults:   tt.defaults,
+				NoDefaults: tt.noDefaults,
+				fs:         fs,
+			}
+
+			devices, err := s.Scan()
+			if err != nil {
+				t.Fatalf("failed to scan: %v", err)
+			}
+
+			var in5 bool
+			for _, sensor := range devices[0].Sensors {
+				v := sensor.(*VoltageSensor)
+				switch v.Name {
+				case "in0":
+					if want, got := tt.in0, v.Label; want != got {
+						t.Fatalf("unexpected in0 label: %q != %q", want, got)
+					}
+				case "in4":
+					if want, got := tt.in4, v.Input; math.Abs(want-got) > 1e-9 {
+						t.Fatalf("unexpected in4 input: %v != %v", want, got)
+					}
+				case "in5":
+					in5 = true
+				}
+			}
+
+			if want, got := tt.in5, in5; want != got {
+				t.Fatalf("unexpected presence of in5: %v != %v", want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigChips(t *testing.T) {
+	// Every chip in the database should be recognized by its default name
+	for _, name := range []string{"it8712", "it8720", "nct6775", "nct6798", "w83627ehf", "w83627thf"} {
+		d := &Device{Name: name + "-00"}
+		if !DefaultConfig.matches(d) {
+			t.Fatalf("no defaults for chip %q", name)
+		}
+	}
+
+	if d := (&Device{Name: "it8728-00"}); DefaultConfig.matches(d) {
+		t.Fatal("unexpected defaults for chip it8728")
+	}
+
+	if !strings.Contains(DefaultConfig.Chips[0].Position.File, "built-in") {
+		t.Fatalf("unexpected position: %v", DefaultConfig.Chips[0].Position)
+	}
+}
@@ -0,0 +1,85 @@
+package lmsensors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errNotScanned is returned when attributes are accessed on a Device which
+// was not produced by a Scanner.
+var errNotScanned = errors.New("lmsensors: device was not produced by a Scanner")
+
+// A PermissionError is returned when a sensor attribute cannot be written
+// because the caller lacks permission, typically because it is not running
+// as root.
+type PermissionError struct {
+	// The path to the attribute which could not be written.
+	Path string
+
+	// The underlying error returned by the filesystem.
+	Err error
+}
+
+// Error implements error.
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("lmsensors: permission denied writing %q: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error, so PermissionErrors may be matched
+// against os.ErrPermission.
+func (e *PermissionError) Unwrap() error { return e.Err }
+
+// chip returns the name of the Device as reported by its driver, without
+// the numeric suffix added by the Scanner.
+func (d *Device) chip() string {
+	i := strings.LastIndex(d.Name, "-")
+	if i == -1 {
+		return d.Name
+	}
+
+	return d.Name[:i]
+}
+
+// now returns the current time according to the Device's clock.
+func (d *Device) now() time.Time {
+	if d.clock == nil {
+		return time.Now()
+	}
+
+	return d.clock.Now()
+}
+
+// readAttribute reads the attribute file from the Device's directory.
+func (d *Device) readAttribute(file string) (string, error) {
+	if d.fs == nil {
+		return "", errNotScanned
+	}
+
+	return d.fs.ReadFile(filepath.Join(d.Path, file))
+}
+
+// writeAttribute writes value to the attribute file in the Device's
+// directory.
+func (d *Device) writeAttribute(file, value string) error {
+	if d.fs == nil {
+		return errNotScanned
+	}
+
+	path := filepath.Join(d.Path, file)
+	if err := d.fs.WriteFile(path, value); err != nil {
+		if os.IsPermission(err) {
+			return &PermissionError{
+				Path: path,
+				Err:  err,
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
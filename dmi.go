@@ -0,0 +1,108 @@
+package lmsensors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dmiPath is the location of DMI identity attributes in the /sys filesystem.
+const dmiPath = "/sys/class/dmi/id"
+
+// A DMI identifies the machine on which Devices were scanned, as reported
+// by its firmware.  Fields which the firmware does not report are empty.
+type DMI struct {
+	BoardVendor string
+	BoardName   string
+	ProductName string
+	BIOSVersion string
+}
+
+// readDMI reads the identity of the machine.  If no identity attributes can
+// be read, readDMI returns nil.
+func (s *Scanner) readDMI() *DMI {
+	var (
+		dmi DMI
+		ok  bool
+	)
+
+	for _, a := range []struct {
+		file string
+		v    *string
+	}{
+		{file: "board_vendor", v: &dmi.BoardVendor},
+		{file: "board_name", v: &dmi.BoardName},
+		{file: "product_name", v: &dmi.ProductName},
+		{file: "bios_version", v: &dmi.BIOSVersion},
+	} {
+		v, err := s.fs.ReadFile(filepath.Join(dmiPath, a.file))
+		if err != nil {
+			continue
+		}
+
+		*a.v = v
+		ok = true
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return &dmi
+}
+
+// LoadBoardProfile loads the board profile for the machine from directory
+// dir, selected by the machine's DMI identity.  Board profiles use the
+// sensors.conf format, and are stored as "<board vendor>/<board name>.conf",
+// or "<board vendor>/<product name>.conf" for machines which identify
+// themselves by product, with any "/" in names replaced by "_".
+//
+// If no profile exists for the machine, LoadBoardProfile returns nil and no
+// error.  Scanners load board profiles from their BoardProfiles directory
+// automatically, so LoadBoardProfile is only needed to load a profile once
+// and assign it to a Scanner's Board field.
+func (s *Scanner) LoadBoardProfile(dir string) (*Config, error) {
+	return s.loadBoardProfile(s.readDMI(), dir)
+}
+
+// loadBoardProfile loads the board profile for the machine identified by
+// dmi from directory dir.
+func (s *Scanner) loadBoardProfile(dmi *DMI, dir string) (*Config, error) {
+	if dmi == nil || dmi.BoardVendor == "" {
+		return nil, nil
+	}
+
+	for _, name := range []string{dmi.BoardName, dmi.ProductName} {
+		if name == "" {
+			continue
+		}
+
+		file := filepath.Join(dir, profileName(dmi.BoardVendor), profileName(name)+".conf")
+		if _, err := s.fs.Stat(file); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		b, err := s.fs.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Config{}
+		if err := c.parse(b, file); err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+
+	return nil, nil
+}
+
+// profileName converts a DMI identity string into a file name.
+func profileName(s string) string {
+	return strings.Replace(strings.TrimSpace(s), "/", "_", -1)
+}
@@ -0,0 +1,173 @@
+package lmsensors
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScannerBoardProfile(t *testing.T) {
+	const dir = "/sys/devices/platform/nct6775.656/hwmon/hwmon1"
+
+	files := []memoryFile{
+		{
+			name: "/sys/class/hwmon",
+			info: &memoryFileInfo{
+				isDir: true,
+			},
+		},
+		{
+			name: "/sys/class/hwmon/hwmon1",
+			info: &memoryFileInfo{
+				mode: os.ModeSymlink,
+			},
+		},
+		{
+			name: dir,
+			info: &memoryFileInfo{
+				isDir: true,
+			},
+		},
+		{name: dir + "/name", contents: "nct6779"},
+		{name: dir + "/in0_input", contents: "1200"},
+		{name: dir + "/in1_input", contents: "1008"},
+		{
+			name:     "/etc/sensors/boards/ASUSTeK COMPUTER INC./PRIME X370-PRO.conf",
+			contents: "chip \"nct6779-*\"\n    label in1 \"+5V\"\n    compute in1 @*5, @/5",
+		},
+		{
+			name:     "/etc/sensors/boards/LENOVO/ThinkCentre M700_M900.conf",
+			contents: "chip \"nct6779-*\"\n    label in1 \"+12V\"",
+		},
+	}
+
+	dmi := func(vendor, board, product string) []memoryFile {
+		var fs []memoryFile
+		for _, f := range []memoryFile{
+			{name: "/sys/class/dmi/id/board_vendor", contents: vendor},
+			{name: "/sys/class/dmi/id/board_name", contents: board},
+			{name: "/sys/class/dmi/id/product_name", contents: product},
+			{name: "/sys/class/dmi/id/bios_version", contents: "4406"},
+		} {
+			if f.contents != "" {
+				fs = append(fs, f)
+			}
+		}
+
+		return fs
+	}
+
+	tests := []struct {
+		name  string
+		dmi   []memoryFile
+		ident *DMI
+		label string
+		in1   float64
+	}{
+		{
+			name: "by board name",
+			dmi:  dmi("ASUSTeK COMPUTER INC.", "PRIME X370-PRO", "System Product Name"),
+			ident: &DMI{
+				BoardVendor: "ASUSTeK COMPUTER INC.",
+				BoardName:   "PRIME X370-PRO",
+				ProductName: "System Product Name",
+				BIOSVersion: "4406",
+			},
+			label: "+5V",
+			in1:   5.04,
+		},
+		{
+			name: "by product name",
+			dmi:  dmi("LENOVO", "3102", "ThinkCentre M700/M900"),
+			ident: &DMI{
+				BoardVendor: "LENOVO",
+				BoardName:   "3102",
+				ProductName: "ThinkCentre M700/M900",
+				BIOSVersion: "4406",
+			},
+			label: "+12V",
+			in1:   1.008,
+		},
+		{
+			name: "no profile",
+			dmi:  dmi("Gigabyte Technology Co., Ltd.", "X570 AORUS ELITE", ""),
+			ident: &DMI{
+				BoardVendor: "Gigabyte Technology Co., Ltd.",
+				BoardName:   "X570 AORUS ELITE",
+				BIOSVersion: "4406",
+			},
+			in1: 1.008,
+		},
+		{
+			name: "no DMI",
+			in1:  1.008,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{
+				BoardProfiles: "/etc/sensors/boards",
+				fs: &memoryFilesystem{
+					symlinks: map[string]string{
+						"/sys/class/hwmon/hwmon1": "../../devices/platform/nct6775.656/hwmon/hwmon1",
+					},
+					files: append(append([]memoryFile(nil), files...), tt.dmi...),
+				},
+			}
+
+			devices, err := s.Scan()
+			if err != nil {
+				t.Fatalf("failed to scan: %v", err)
+			}
+
+			d := devices[0]
+			if want, got := tt.ident, d.DMI; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected DMI:\n- want: %#v\n-  got: %#v", want, got)
+			}
+
+			in0 := d.Sensors[0].(*VoltageSensor)
+			in1 := d.Sensors[1].(*VoltageSensor)
+
+			// Chip defaults only apply when no board profile matches
+			wantIn0 := "Vcore"
+			if tt.label != "" {
+				wantIn0 = ""
+			}
+
+			if want, got := wantIn0, in0.Label; want != got {
+				t.Fatalf("unexpected in0 label: %q != %q", want, got)
+			}
+			if want, got := tt.label, in1.Label; want != got {
+				t.Fatalf("unexpected in1 label: %q != %q", want, got)
+			}
+			if want, got := tt.in1, in1.Input; want != got {
+				t.Fatalf("unexpected in1 input: %v != %v", want, got)
+			}
+		})
+	}
+}
+
+func TestScannerBoardProfileError(t *testing.T) {
+	s := &Scanner{
+		BoardProfiles: "/etc/sensors/boards",
+		fs: &memoryFilesystem{
+			files: []memoryFile{
+				{
+					name: "/sys/class/hwmon",
+					info: &memoryFileInfo{
+						isDir: true,
+					},
+				},
+				{name: "/sys/class/dmi/id/board_vendor", contents: "LENOVO"},
+				{name: "/sys/class/dmi/id/board_name", contents: "3102"},
+				{name: "/etc/sensors/boards/LENOVO/3102.conf", contents: "chip"},
+			},
+		},
+	}
+
+	if _, err := s.ScanResults(); err == nil || !strings.Contains(err.Error(), "3102.conf") {
+		t.Fatalf("expected a board profile error, but got: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package lmsensors
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	// Sensors are encoded as interface values by gob, so their concrete
+	// types must be registered
+	for _, s := range sensorKinds {
+		gob.Register(newSensor(s))
+	}
+}
+
+// kinds maps the kind discriminators of encoded Sensors to Sensor name
+// prefixes.
+var kinds = map[string]string{
+	"current":     "curr",
+	"fan":         "fan",
+	"intrusion":   "intrusion",
+	"power":       "power",
+	"pwm":         "pwm",
+	"temperature": "temp",
+	"voltage":     "in",
+}
+
+// sensorKind returns the kind discriminator of Sensor s.
+func sensorKind(s Sensor) string {
+	switch s.(type) {
+	case *CurrentSensor:
+		return "current"
+	case *FanSensor:
+		return "fan"
+	case *IntrusionSensor:
+		return "intrusion"
+	case *PowerSensor:
+		return "power"
+	case *PWMSensor:
+		return "pwm"
+	case *TemperatureSensor:
+		return "temperature"
+	case *VoltageSensor:
+		return "voltage"
+	}
+
+	return ""
+}
+
+// A device is the encoded form of a Device.  Devices keep their chip name,
+// so that Devices parsed from the output of "sensors" keep it as well.
+type device struct {
+	Name       string
+	Path       string
+	Hwmon      string
+	DevicePath string
+	Adapter    string
+	Chip       string
+	DMI        *DMI
+}
+
+// newDevice creates the encoded form of Device d.
+func newDevice(d *Device) device {
+	return device{
+		Name:       d.Name,
+		Path:       d.Path,
+		Hwmon:      d.Hwmon,
+		DevicePath: d.DevicePath,
+		Adapter:    d.Adapter,
+		Chip:       d.ChipName().String(),
+		DMI:        d.DMI,
+	}
+}
+
+// decode sets the fields of Device d from its encoded form.
+func (dev device) decode(d *Device) error {
+	*d = Device{
+		Name:       dev.Name,
+		Path:       dev.Path,
+		Hwmon:      dev.Hwmon,
+		DevicePath: dev.DevicePath,
+		Adapter:    dev.Adapter,
+		DMI:        dev.DMI,
+	}
+
+	if dev.Chip != "" {
+		c, err := ParseChipName(dev.Chip)
+		if err != nil {
+			return err
+		}
+
+		d.chipName = &c
+	}
+
+	return nil
+}
+
+// A jsonDevice is the JSON form of a Device.
+type jsonDevice struct {
+	device
+	Sensors []jsonSensor
+}
+
+// A jsonSensor is the JSON form of a Sensor: an object with the Sensor's
+// fields and a "Kind" member which identifies its type.
+type jsonSensor struct {
+	Sensor
+}
+
+// MarshalJSON implements json.Marshaler.  Each Sensor is encoded with a
+// "Kind" member, such as "temperature", which identifies its type.
+func (d *Device) MarshalJSON() ([]byte, error) {
+	jd := jsonDevice{
+		device:  newDevice(d),
+		Sensors: make([]jsonSensor, 0, len(d.Sensors)),
+	}
+
+	for _, s := range d.Sensors {
+		jd.Sensors = append(jd.Sensors, jsonSensor{Sensor: s})
+	}
+
+	return json.Marshal(jd)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Device) UnmarshalJSON(b []byte) error {
+	var jd jsonDevice
+	if err := json.Unmarshal(b, &jd); err != nil {
+		return err
+	}
+
+	if err := jd.decode(d); err != nil {
+		return err
+	}
+
+	for _, s := range jd.Sensors {
+		d.Sensors = append(d.Sensors, s.Sensor)
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s jsonSensor) MarshalJSON() ([]byte, error) {
+	kind := sensorKind(s.Sensor)
+	if kind == "" {
+		return nil, fmt.Errorf("lmsensors: cannot encode sensor of type %T", s.Sensor)
+	}
+
+	b, err := json.Marshal(s.Sensor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert the kind as the first member of the Sensor's object
+	k, _ := json.Marshal(kind)
+	out := append([]byte(`{"Kind":`), k...)
+	if len(b) > 2 {
+		out = append(out, ',')
+	}
+
+	return append(out, b[1:]...), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *jsonSensor) UnmarshalJSON(b []byte) error {
+	var k struct {
+		Kind string
+	}
+	if err := json.Unmarshal(b, &k); err != nil {
+		return err
+	}
+
+	prefix, ok := kinds[k.Kind]
+	if !ok {
+		return fmt.Errorf("lmsensors: unknown sensor kind %q", k.Kind)
+	}
+
+	s.Sensor = newSensor(prefix)
+	return json.Unmarshal(b, s.Sensor)
+}
+
+// A gobDevice is the gob form of a Device.
+type gobDevice struct {
+	Device  device
+	Sensors []Sensor
+}
+
+// GobEncode implements gob.GobEncoder.
+func (d *Device) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobDevice{
+		Device:  newDevice(d),
+		Sensors: d.Sensors,
+	})
+
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder.
+func (d *Device) GobDecode(b []byte) error {
+	var gd gobDevice
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&gd); err != nil {
+		return err
+	}
+
+	if err := gd.Device.decode(d); err != nil {
+		return err
+	}
+
+	d.Sensors = gd.Sensors
+	return nil
+}
@@ -0,0 +1,122 @@
+package lmsensors
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeviceEncoding(t *testing.T) {
+	// Decoded Devices keep the chip name of the encoded Device
+	want := testDevices(t)
+	for _, d := range want {
+		c := d.ChipName()
+		d.chipName = &c
+	}
+
+	tests := []struct {
+		name   string
+		decode func(t *testing.T, devices []*Device) []*Device
+	}{
+		{
+			name: "JSON",
+			decode: func(t *testing.T, devices []*Device) []*Device {
+				b, err := json.Marshal(devices)
+				if err != nil {
+					t.Fatalf("failed to marshal: %v", err)
+				}
+
+				var out []*Device
+				if err := json.Unmarshal(b, &out); err != nil {
+					t.Fatalf("failed to unmarshal: %v", err)
+				}
+
+				return out
+			},
+		},
+		{
+			name: "gob",
+			decode: func(t *testing.T, devices []*Device) []*Device {
+				var buf bytes.Buffer
+				if err := gob.NewEncoder(&buf).Encode(devices); err != nil {
+					t.Fatalf("failed to encode: %v", err)
+				}
+
+				var out []*Device
+				if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+					t.Fatalf("failed to decode: %v", err)
+				}
+
+				return out
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.decode(t, testDevices(t))
+
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Devices:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestDeviceMarshalJSON(t *testing.T) {
+	d := &Device{
+		Name: "acpitz-00",
+		Sensors: []Sensor{
+			&TemperatureSensor{
+				Name:  "temp1",
+				Input: 27.8,
+			},
+		},
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if want, got := `"Sensors":[{"Kind":"temperature","Name":"temp1",`, string(b); !strings.Contains(got, want) {
+		t.Fatalf("unexpected JSON:\n- want: %s\n-  got: %s", want, got)
+	}
+}
+
+func TestDeviceUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "unknown kind",
+			input: `{"Name":"acpitz-00","Sensors":[{"Kind":"humidity","Name":"humidity1"}]}`,
+			err:   `lmsensors: unknown sensor kind "humidity"`,
+		},
+		{
+			name:  "no kind",
+			input: `{"Name":"acpitz-00","Sensors":[{"Name":"temp1"}]}`,
+			err:   `lmsensors: unknown sensor kind ""`,
+		},
+		{
+			name:  "bad chip name",
+			input: `{"Name":"acpitz-00","Chip":"acpitz-foo"}`,
+			err:   `lmsensors: invalid bus type in chip name "acpitz-foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Device
+			err := json.Unmarshal([]byte(tt.input), &d)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", tt.err, err)
+			}
+		})
+	}
+}
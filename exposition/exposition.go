@@ -0,0 +1,163 @@
+// Package exposition writes lmsensors Devices in the Prometheus text and
+// OpenMetrics exposition formats, without depending on the Prometheus
+// client library.
+//
+// Metrics are named as they are by the hwmon collector of the Prometheus
+// node_exporter, and by package collector.
+package exposition
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/hwmon"
+)
+
+// Content types of the exposition formats.
+const (
+	TextContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// An Encoder writes Devices as metrics in an exposition format.
+type Encoder struct {
+	// OpenMetrics specifies that metrics are written in the OpenMetrics
+	// format, rather than the Prometheus text format.
+	OpenMetrics bool
+
+	// Timestamp, if non-zero, is written with every sample.  Prometheus
+	// discards samples with timestamps which are too old, so timestamps
+	// should only be set when Devices were scanned recently.
+	Timestamp time.Time
+
+	w io.Writer
+}
+
+// NewEncoder creates an Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the metrics of devices to the Encoder's io.Writer.
+func (e *Encoder) Encode(devices []*lmsensors.Device) error {
+	var samples []hwmon.Sample
+	for i, chip := range hwmon.ChipLabels(devices) {
+		samples = append(samples, hwmon.DeviceSamples(devices[i], chip)...)
+	}
+
+	return e.encode(samples)
+}
+
+// encodeResults writes the metrics of the Devices in results, and the
+// result of scanning each of them, to the Encoder's io.Writer.
+func (e *Encoder) encodeResults(results []*lmsensors.ScanResult) error {
+	devices := make([]*lmsensors.Device, 0, len(results))
+	for _, r := range results {
+		devices = append(devices, r.Device)
+	}
+
+	var samples []hwmon.Sample
+	for i, chip := range hwmon.ChipLabels(devices) {
+		r := results[i]
+
+		samples = append(samples, hwmon.ScrapeSamples(r, chip)...)
+		if r.Err == nil {
+			samples = append(samples, hwmon.DeviceSamples(r.Device, chip)...)
+		}
+	}
+
+	return e.encode(samples)
+}
+
+// encode writes samples to the Encoder's io.Writer, grouped into metric
+// families which are sorted by name.
+func (e *Encoder) encode(samples []hwmon.Sample) error {
+	families := make(map[string][]hwmon.Sample)
+	var names []string
+	for _, s := range samples {
+		if _, ok := families[s.Name]; !ok {
+			names = append(names, s.Name)
+		}
+
+		families[s.Name] = append(families[s.Name], s)
+	}
+	sort.Strings(names)
+
+	help := textHelpEscaper
+	if e.OpenMetrics {
+		help = labelValueEscaper
+	}
+
+	bw := bufio.NewWriter(e.w)
+	for _, name := range names {
+		f := families[name]
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, help.Replace(f[0].Help))
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+
+		for _, s := range f {
+			bw.WriteString(name)
+			if len(s.Labels) > 0 {
+				labels := make([]string, 0, len(s.Labels))
+				for _, l := range s.Labels {
+					labels = append(labels, fmt.Sprintf(`%s="%s"`, l.Name, labelValueEscaper.Replace(l.Value)))
+				}
+
+				fmt.Fprintf(bw, "{%s}", strings.Join(labels, ","))
+			}
+
+			fmt.Fprintf(bw, " %s", formatFloat(s.Value))
+			if !e.Timestamp.IsZero() {
+				fmt.Fprintf(bw, " %s", e.timestamp())
+			}
+
+			bw.WriteString("\n")
+		}
+	}
+
+	if e.OpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+// timestamp formats the Encoder's Timestamp: in milliseconds for the
+// Prometheus text format, or in seconds for OpenMetrics.
+func (e *Encoder) timestamp() string {
+	if e.OpenMetrics {
+		return strconv.FormatFloat(float64(e.Timestamp.UnixNano())/1e9, 'f', -1, 64)
+	}
+
+	return strconv.FormatInt(e.Timestamp.UnixNano()/1e6, 10)
+}
+
+var (
+	// labelValueEscaper escapes label values in both formats, and help
+	// text in OpenMetrics
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+	// textHelpEscaper escapes help text in the Prometheus text format
+	textHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
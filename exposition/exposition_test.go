@@ -0,0 +1,165 @@
+package exposition
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// testDevices returns Devices used to test the exposition formats.
+func testDevices() []*lmsensors.Device {
+	return []*lmsensors.Device{
+		{
+			Name:       "coretemp-00",
+			Hwmon:      "hwmon1",
+			DevicePath: "/sys/devices/platform/coretemp.0",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.TemperatureSensor{
+					Name:     "temp1",
+					Label:    `Package "id" (0)`,
+					Input:    43.0,
+					Critical: 100.0,
+				},
+			},
+		},
+		{
+			Name:       "thinkpad-00",
+			Hwmon:      "hwmon0",
+			DevicePath: "/sys/devices/platform/thinkpad_hwmon",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.FanSensor{
+					Name:  "fan1",
+					Input: 1130,
+				},
+			},
+		},
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	ts := time.Unix(1500000000, 500*int64(time.Millisecond))
+
+	tests := []struct {
+		name        string
+		openMetrics bool
+		timestamp   time.Time
+		want        string
+	}{
+		{
+			name: "text",
+			want: `# HELP node_hwmon_chip_names Annotation metric for human-readable chip names
+# TYPE node_hwmon_chip_names gauge
+node_hwmon_chip_names{chip="platform_coretemp_0",chip_name="coretemp"} 1
+node_hwmon_chip_names{chip="platform_thinkpad_hwmon",chip_name="thinkpad"} 1
+# HELP node_hwmon_fan_alarm Hardware sensor alarm status (fan)
+# TYPE node_hwmon_fan_alarm gauge
+node_hwmon_fan_alarm{chip="platform_thinkpad_hwmon",sensor="fan1"} 0
+# HELP node_hwmon_fan_rpm Hardware monitor for fan revolutions per minute (input)
+# TYPE node_hwmon_fan_rpm gauge
+node_hwmon_fan_rpm{chip="platform_thinkpad_hwmon",sensor="fan1"} 1130
+# HELP node_hwmon_sensor_label Label for given chip and sensor
+# TYPE node_hwmon_sensor_label gauge
+node_hwmon_sensor_label{chip="platform_coretemp_0",sensor="temp1",label="Package \"id\" (0)"} 1
+# HELP node_hwmon_temp_alarm Hardware sensor alarm status (temp)
+# TYPE node_hwmon_temp_alarm gauge
+node_hwmon_temp_alarm{chip="platform_coretemp_0",sensor="temp1"} 0
+# HELP node_hwmon_temp_celsius Hardware monitor for temperature (input)
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1"} 43
+# HELP node_hwmon_temp_crit_alarm_celsius Hardware monitor for temperature (crit_alarm)
+# TYPE node_hwmon_temp_crit_alarm_celsius gauge
+node_hwmon_temp_crit_alarm_celsius{chip="platform_coretemp_0",sensor="temp1"} 0
+# HELP node_hwmon_temp_crit_celsius Hardware monitor for temperature (crit)
+# TYPE node_hwmon_temp_crit_celsius gauge
+node_hwmon_temp_crit_celsius{chip="platform_coretemp_0",sensor="temp1"} 100
+`,
+		},
+		{
+			name:        "OpenMetrics with timestamps",
+			openMetrics: true,
+			timestamp:   ts,
+			want: `# HELP node_hwmon_chip_names Annotation metric for human-readable chip names
+# TYPE node_hwmon_chip_names gauge
+node_hwmon_chip_names{chip="platform_coretemp_0",chip_name="coretemp"} 1 1500000000.5
+node_hwmon_chip_names{chip="platform_thinkpad_hwmon",chip_name="thinkpad"} 1 1500000000.5
+# HELP node_hwmon_fan_alarm Hardware sensor alarm status (fan)
+# TYPE node_hwmon_fan_alarm gauge
+node_hwmon_fan_alarm{chip="platform_thinkpad_hwmon",sensor="fan1"} 0 1500000000.5
+# HELP node_hwmon_fan_rpm Hardware monitor for fan revolutions per minute (input)
+# TYPE node_hwmon_fan_rpm gauge
+node_hwmon_fan_rpm{chip="platform_thinkpad_hwmon",sensor="fan1"} 1130 1500000000.5
+# HELP node_hwmon_sensor_label Label for given chip and sensor
+# TYPE node_hwmon_sensor_label gauge
+node_hwmon_sensor_label{chip="platform_coretemp_0",sensor="temp1",label="Package \"id\" (0)"} 1 1500000000.5
+# HELP node_hwmon_temp_alarm Hardware sensor alarm status (temp)
+# TYPE node_hwmon_temp_alarm gauge
+node_hwmon_temp_alarm{chip="platform_coretemp_0",sensor="temp1"} 0 1500000000.5
+# HELP node_hwmon_temp_celsius Hardware monitor for temperature (input)
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1"} 43 1500000000.5
+# HELP node_hwmon_temp_crit_alarm_celsius Hardware monitor for temperature (crit_alarm)
+# TYPE node_hwmon_temp_crit_alarm_celsius gauge
+node_hwmon_temp_crit_alarm_celsius{chip="platform_coretemp_0",sensor="temp1"} 0 1500000000.5
+# HELP node_hwmon_temp_crit_celsius Hardware monitor for temperature (crit)
+# TYPE node_hwmon_temp_crit_celsius gauge
+node_hwmon_temp_crit_celsius{chip="platform_coretemp_0",sensor="temp1"} 100 1500000000.5
+# EOF
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.OpenMetrics = tt.openMetrics
+			e.Timestamp = tt.timestamp
+
+			if err := e.Encode(testDevices()); err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+
+			if want, got := tt.want, buf.String(); want != got {
+				t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+			}
+		})
+	}
+}
+
+func TestEncoderTextTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.Timestamp = time.Unix(1500000000, 500*int64(time.Millisecond))
+
+	if err := e.Encode(testDevices()[1:]); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	const want = `node_hwmon_fan_rpm{chip="platform_thinkpad_hwmon",sensor="fan1"} 1130 1500000000500` + "\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("output does not contain %q:\n%s", want, got)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{v: 0.696, want: "0.696"},
+		{v: 1e21, want: "1e+21"},
+		{v: math.Inf(1), want: "+Inf"},
+		{v: math.Inf(-1), want: "-Inf"},
+		{v: math.NaN(), want: "NaN"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFloat(tt.v); tt.want != got {
+			t.Fatalf("unexpected value for %v:\n- want: %q\n-  got: %q", tt.v, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package exposition
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// A Scanner scans for Devices.  It is implemented by *lmsensors.Scanner.
+type Scanner interface {
+	ScanResults() ([]*lmsensors.ScanResult, error)
+}
+
+var _ Scanner = &lmsensors.Scanner{}
+
+// A Handler is an http.Handler which scans for Devices on each request,
+// and serves their metrics.
+//
+// Metrics are served in the OpenMetrics format if the request accepts it,
+// and in the Prometheus text format otherwise.  The time taken to scan each
+// Device, and whether or not scanning it failed, are served as
+// node_hwmon_scrape_duration_seconds and node_hwmon_scrape_error.
+type Handler struct {
+	// Timestamps specifies that samples are served with the time at which
+	// Devices were scanned.
+	Timestamps bool
+
+	// ErrorLog specifies an optional logger for errors which occur while
+	// writing a response, after which no error status can be served.  If
+	// nil, such errors are ignored.
+	ErrorLog *log.Logger
+
+	s   Scanner
+	now func() time.Time
+}
+
+var _ http.Handler = &Handler{}
+
+// NewHandler creates a Handler which scans for Devices using s.
+func NewHandler(s Scanner) *Handler {
+	return &Handler{
+		s:   s,
+		now: time.Now,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := h.now()
+
+	results, err := h.s.ScanResults()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Metrics are buffered so a failure can still be reported with an
+	// error status
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.OpenMetrics = acceptsOpenMetrics(r.Header.Get("Accept"))
+	if h.Timestamps {
+		e.Timestamp = start
+	}
+
+	if err := e.encodeResults(results); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := TextContentType
+	if e.OpenMetrics {
+		contentType = OpenMetricsContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(buf.Bytes()); err != nil && h.ErrorLog != nil {
+		h.ErrorLog.Printf("lmsensors: failed to write metrics: %v", err)
+	}
+}
+
+// acceptsOpenMetrics reports whether an HTTP Accept header prefers the
+// OpenMetrics format.  OpenMetrics is preferred if it is accepted with a
+// nonzero quality which is at least that of the Prometheus text format.
+func acceptsOpenMetrics(accept string) bool {
+	var om, text float64
+	for _, r := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(r)
+		if err != nil {
+			continue
+		}
+
+		// Media ranges without a quality value are fully acceptable
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mt {
+		case "application/openmetrics-text":
+			om = math.Max(om, q)
+		case "text/plain", "text/*", "*/*":
+			text = math.Max(text, q)
+		}
+	}
+
+	return om > 0 && om >= text
+}
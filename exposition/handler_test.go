@@ -0,0 +1,155 @@
+package exposition
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/lmsensorstest"
+)
+
+func TestHandler(t *testing.T) {
+	devices := testDevices()
+
+	s := &lmsensorstest.Scanner{
+		Results: []*lmsensors.ScanResult{
+			{
+				Device:   devices[0],
+				Duration: 250 * time.Millisecond,
+			},
+			{
+				Device:   devices[1],
+				Duration: 100 * time.Millisecond,
+				Err:      errors.New("broken"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		accept      string
+		timestamps  bool
+		contentType string
+		contains    []string
+		excludes    []string
+	}{
+		{
+			name:        "text",
+			contentType: TextContentType,
+			contains: []string{
+				`node_hwmon_scrape_duration_seconds{chip="platform_coretemp_0"} 0.25` + "\n",
+				`node_hwmon_scrape_error{chip="platform_coretemp_0"} 0` + "\n",
+				`node_hwmon_scrape_error{chip="platform_thinkpad_hwmon"} 1` + "\n",
+				`node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1"} 43` + "\n",
+			},
+			excludes: []string{
+				// Devices which could not be scanned have no readings
+				"node_hwmon_fan_rpm",
+				"# EOF",
+			},
+		},
+		{
+			name:        "OpenMetrics",
+			accept:      "application/openmetrics-text; version=1.0.0,text/plain;q=0.5",
+			contentType: OpenMetricsContentType,
+			contains:    []string{"# EOF\n"},
+		},
+		{
+			name:        "OpenMetrics not acceptable",
+			accept:      "application/openmetrics-text;q=0,text/plain",
+			contentType: TextContentType,
+			excludes:    []string{"# EOF"},
+		},
+		{
+			name:        "text preferred",
+			accept:      "application/openmetrics-text;q=0.3,text/plain;version=0.0.4;q=0.5",
+			contentType: TextContentType,
+			excludes:    []string{"# EOF"},
+		},
+		{
+			name:        "timestamps",
+			timestamps:  true,
+			contentType: TextContentType,
+			contains: []string{
+				`node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1"} 43 1500000000000` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(s)
+			h.Timestamps = tt.timestamps
+			h.now = func() time.Time { return time.Unix(1500000000, 0) }
+
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if want, got := http.StatusOK, w.Code; want != got {
+				t.Fatalf("unexpected status code: %d != %d", want, got)
+			}
+			if want, got := tt.contentType, w.Header().Get("Content-Type"); want != got {
+				t.Fatalf("unexpected content type:\n- want: %q\n-  got: %q", want, got)
+			}
+
+			body := w.Body.String()
+			for _, c := range tt.contains {
+				if !strings.Contains(body, c) {
+					t.Fatalf("body does not contain %q:\n%s", c, body)
+				}
+			}
+			for _, e := range tt.excludes {
+				if strings.Contains(body, e) {
+					t.Fatalf("body unexpectedly contains %q:\n%s", e, body)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerScanError(t *testing.T) {
+	h := NewHandler(&lmsensorstest.Scanner{Err: errors.New("no devices")})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if want, got := http.StatusInternalServerError, w.Code; want != got {
+		t.Fatalf("unexpected status code: %d != %d", want, got)
+	}
+
+	b, _ := ioutil.ReadAll(w.Body)
+	if want, got := "no devices\n", string(b); want != got {
+		t.Fatalf("unexpected body:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestHandlerWriteError(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&lmsensorstest.Scanner{})
+	h.ErrorLog = log.New(&buf, "", 0)
+
+	h.ServeHTTP(&errWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if want, got := "lmsensors: failed to write metrics: broken pipe\n", buf.String(); want != got {
+		t.Fatalf("unexpected log output:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+// An errWriter is an http.ResponseWriter which fails to write responses.
+type errWriter struct {
+	http.ResponseWriter
+}
+
+func (w *errWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
@@ -0,0 +1,122 @@
+package lmsensors
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// An Expr is an arithmetic expression from a "compute" or "set" statement
+// in an lm-sensors configuration.
+type Expr interface {
+	// Eval evaluates the expression.  raw is the value of "@", and
+	// features resolves references to other features on the same chip,
+	// such as "in0".
+	Eval(raw float64, features func(name string) (float64, error)) (float64, error)
+
+	// String returns the expression in configuration file syntax.
+	String() string
+}
+
+var (
+	_ Expr = numberExpr(0)
+	_ Expr = rawExpr{}
+	_ Expr = featureExpr("")
+	_ Expr = &unaryExpr{}
+	_ Expr = &binaryExpr{}
+)
+
+// A numberExpr is a constant.
+type numberExpr float64
+
+func (e numberExpr) Eval(float64, func(string) (float64, error)) (float64, error) {
+	return float64(e), nil
+}
+
+func (e numberExpr) String() string { return strconv.FormatFloat(float64(e), 'g', -1, 64) }
+
+// A rawExpr is "@", the value being transformed.
+type rawExpr struct{}
+
+func (rawExpr) Eval(raw float64, _ func(string) (float64, error)) (float64, error) {
+	return raw, nil
+}
+
+func (rawExpr) String() string { return "@" }
+
+// A featureExpr is a reference to the value of another feature on the same
+// chip, such as "in0".
+type featureExpr string
+
+func (e featureExpr) Eval(_ float64, features func(string) (float64, error)) (float64, error) {
+	if features == nil {
+		return 0, fmt.Errorf("unknown feature %q", string(e))
+	}
+
+	return features(string(e))
+}
+
+func (e featureExpr) String() string { return string(e) }
+
+// A unaryExpr is a prefix operator applied to an expression: negation
+// ("-"), the exponential function ("^"), or the natural logarithm ("`").
+type unaryExpr struct {
+	op byte
+	x  Expr
+}
+
+func (e *unaryExpr) Eval(raw float64, features func(string) (float64, error)) (float64, error) {
+	x, err := e.x.Eval(raw, features)
+	if err != nil {
+		return 0, err
+	}
+
+	switch e.op {
+	case '-':
+		return -x, nil
+	case '^':
+		return math.Exp(x), nil
+	case '`':
+		return math.Log(x), nil
+	}
+
+	panic(fmt.Sprintf("lmsensors: invalid unary operator %q", e.op))
+}
+
+func (e *unaryExpr) String() string { return "(" + string(e.op) + e.x.String() + ")" }
+
+// A binaryExpr is an arithmetic operator ("+", "-", "*", or "/") applied to
+// two expressions.
+type binaryExpr struct {
+	op   byte
+	x, y Expr
+}
+
+func (e *binaryExpr) Eval(raw float64, features func(string) (float64, error)) (float64, error) {
+	x, err := e.x.Eval(raw, features)
+	if err != nil {
+		return 0, err
+	}
+
+	y, err := e.y.Eval(raw, features)
+	if err != nil {
+		return 0, err
+	}
+
+	switch e.op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	case '/':
+		return x / y, nil
+	}
+
+	panic(fmt.Sprintf("lmsensors: invalid binary operator %q", e.op))
+}
+
+func (e *binaryExpr) String() string {
+	return "(" + e.x.String() + " " + string(e.op) + " " + e.y.String() + ")"
+}
@@ -0,0 +1,366 @@
+package fancontrol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// CalibrateOptions specify options for Calibrate.
+type CalibrateOptions struct {
+	// The change in duty cycle between each measurement.  If zero, a
+	// step of 10 is used.
+	Step int
+
+	// The time to wait for fan speeds to settle after each change in duty
+	// cycle.  If zero, 5 seconds is used.
+	Delay time.Duration
+}
+
+// A CalibrationPoint is the speed of each fan driven by a PWM output at a
+// given duty cycle.
+type CalibrationPoint struct {
+	// The duty cycle of the PWM output.
+	Duty int
+
+	// The speed of each fan in rotations per minute, in the same order as
+	// the Output's Fans.
+	RPM []int
+}
+
+// Defaults for the values of a Config generated by Calibrate which cannot
+// be measured, as suggested by pwmconfig.
+const (
+	defaultInterval = 10 * time.Second
+	defaultMinTemp  = 20
+	defaultMaxTemp  = 60
+)
+
+// Calibrate determines which fans are driven by each PWM output found by
+// s, and measures the duty cycles at which those fans start and stop, and
+// their speed across the range of duty cycles, as pwmconfig does.  If opts
+// is nil, default options are used.
+//
+// Calibrate returns a Config which controls every PWM output that drives
+// at least one fan.  Each output is driven by all temperature inputs of its
+// device, so the highest temperature is used, and the interval and
+// temperature range are set to the defaults suggested by pwmconfig.  These
+// values may be changed before the Config is saved with WriteTo.
+//
+// Calibrate takes manual control of every PWM output, and fans are stopped
+// for some time during calibration, so it must not be run under load.  The
+// original control mode and duty cycle of every PWM output are restored
+// when Calibrate returns.
+func Calibrate(ctx context.Context, s *lmsensors.Scanner, opts *CalibrateOptions) (*Config, error) {
+	return calibrate(ctx, s, &systemClock{}, controlPWM, opts)
+}
+
+// calibrate runs a calibration with the specified scanner, clock and means
+// of controlling PWM outputs.
+func calibrate(ctx context.Context, s scanner, clock clock, control controlFunc, opts *CalibrateOptions) (*Config, error) {
+	if opts == nil {
+		opts = &CalibrateOptions{}
+	}
+
+	step, delay := opts.Step, opts.Delay
+	if step <= 0 {
+		step = 10
+	}
+	if delay <= 0 {
+		delay = 5 * time.Second
+	}
+
+	c := &calibrator{
+		s:       s,
+		clock:   clock,
+		control: control,
+		ctx:     ctx,
+		delay:   delay,
+	}
+
+	return c.calibrate(step)
+}
+
+// A calibrator performs a calibration run.
+type calibrator struct {
+	s       scanner
+	clock   clock
+	control controlFunc
+	ctx     context.Context
+	delay   time.Duration
+}
+
+// A calibratedPWM is a PWM output under control during calibration.
+type calibratedPWM struct {
+	path string
+	d    *lmsensors.Device
+	ctrl pwmControl
+}
+
+// calibrate runs a calibration with duty cycles changing by step.
+func (c *calibrator) calibrate(step int) (cfg *Config, err error) {
+	devices, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var pwms []*calibratedPWM
+	defer func() {
+		// Always return outputs to their original state
+		for _, p := range pwms {
+			if cerr := p.ctrl.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}()
+
+	for _, d := range devices {
+		for _, ds := range d.Sensors {
+			ps, ok := ds.(*lmsensors.PWMSensor)
+			if !ok {
+				continue
+			}
+
+			ctrl, err := c.control(d, ps)
+			if err != nil {
+				return nil, err
+			}
+
+			pwms = append(pwms, &calibratedPWM{
+				path: d.Hwmon + "/" + ps.Name,
+				d:    d,
+				ctrl: ctrl,
+			})
+		}
+	}
+
+	// Run all fans at full speed to measure their top speed
+	for _, p := range pwms {
+		if _, err := p.ctrl.Set(255); err != nil {
+			return nil, err
+		}
+	}
+
+	full, err := c.wait()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = &Config{
+		Interval: defaultInterval,
+		DevPath:  make(map[string]string),
+		DevName:  make(map[string]string),
+	}
+
+	for _, p := range pwms {
+		// Stop this output, and look for fans which slow down as a result
+		if _, err := p.ctrl.Set(0); err != nil {
+			return nil, err
+		}
+
+		stopped, err := c.wait()
+		if err != nil {
+			return nil, err
+		}
+
+		var fans []string
+		for f, rpm := range full {
+			if rpm > 0 && stopped[f] < 3*rpm/4 {
+				fans = append(fans, f)
+			}
+		}
+		sort.Strings(fans)
+
+		if _, err := p.ctrl.Set(255); err != nil {
+			return nil, err
+		}
+
+		if len(fans) == 0 {
+			continue
+		}
+
+		temps := temperatures(p.d)
+		if len(temps) == 0 {
+			return nil, fmt.Errorf("fancontrol: no temperature inputs on %s to drive %s", p.d.Hwmon, p.path)
+		}
+
+		o, err := c.sweep(p, fans, step)
+		if err != nil {
+			return nil, err
+		}
+
+		// Leave the fans at full speed while other outputs are calibrated
+		if _, err := p.ctrl.Set(255); err != nil {
+			return nil, err
+		}
+
+		o.Temps = temps
+		o.MinTemp = defaultMinTemp
+		o.MaxTemp = defaultMaxTemp
+		o.MaxPWM = 255
+
+		cfg.Outputs = append(cfg.Outputs, o)
+		cfg.DevName[p.d.Hwmon] = chipName(p.d)
+		if p.d.DevicePath != "" {
+			cfg.DevPath[p.d.Hwmon] = strings.TrimPrefix(p.d.DevicePath, "/sys/")
+		}
+	}
+
+	return cfg, nil
+}
+
+// sweep measures the speed of fans driven by PWM output p as its duty
+// cycle is lowered from full speed until the fans stop, and then raised
+// until the fans start again.
+func (c *calibrator) sweep(p *calibratedPWM, fans []string, step int) (*Output, error) {
+	o := &Output{
+		PWM:  p.path,
+		Fans: fans,
+	}
+
+	var curve []CalibrationPoint
+	for duty := 255; duty >= 0; duty -= step {
+		if _, err := p.ctrl.Set(duty); err != nil {
+			return nil, err
+		}
+
+		rpms, err := c.wait()
+		if err != nil {
+			return nil, err
+		}
+
+		point := CalibrationPoint{Duty: duty}
+		for _, f := range fans {
+			point.RPM = append(point.RPM, rpms[f])
+		}
+
+		curve = append(curve, point)
+		if !spinning(rpms, fans) {
+			break
+		}
+
+		o.MinStop = duty
+	}
+
+	// Curve was measured with decreasing duty cycle
+	for i := len(curve) - 1; i >= 0; i-- {
+		o.Curve = append(o.Curve, curve[i])
+	}
+
+	// If the fans never stopped, they start at any duty cycle at which they
+	// keep spinning
+	if o.Curve[0].Duty == o.MinStop {
+		o.MinStart = o.MinStop
+		return o, nil
+	}
+
+	for duty := o.Curve[0].Duty + step; ; duty += step {
+		if duty > 255 {
+			duty = 255
+		}
+
+		if _, err := p.ctrl.Set(duty); err != nil {
+			return nil, err
+		}
+
+		rpms, err := c.wait()
+		if err != nil {
+			return nil, err
+		}
+
+		if spinning(rpms, fans) {
+			o.MinStart = duty
+			return o, nil
+		}
+
+		if duty == 255 {
+			return nil, fmt.Errorf("fancontrol: fans driven by %s did not start again at full speed", p.path)
+		}
+	}
+}
+
+// wait waits for fan speeds to settle, and then returns the speed of every
+// fan, keyed by path.  Fans with invalid readings are reported as stopped.
+func (c *calibrator) wait() (map[string]int, error) {
+	select {
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	case <-c.clock.After(c.delay):
+	}
+
+	devices, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	rpms := make(map[string]int)
+	for _, d := range devices {
+		for _, ds := range d.Sensors {
+			fs, ok := ds.(*lmsensors.FanSensor)
+			if !ok {
+				continue
+			}
+
+			rpm := fs.Input
+			if !fs.Validity["input"].OK() {
+				rpm = 0
+			}
+
+			rpms[d.Hwmon+"/"+fs.Name+"_input"] = rpm
+		}
+	}
+
+	return rpms, nil
+}
+
+// scan scans for Devices.  Unlike a Controller, a calibration cannot
+// tolerate errors from any device, because a fan which cannot be read
+// would be mistaken for a stopped fan.
+func (c *calibrator) scan() ([]*lmsensors.Device, error) {
+	results, err := c.s.ScanResults()
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*lmsensors.Device, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+
+		devices = append(devices, r.Device)
+	}
+
+	return devices, nil
+}
+
+// spinning reports whether all of fans are spinning.
+func spinning(rpms map[string]int, fans []string) bool {
+	for _, f := range fans {
+		if rpms[f] == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// temperatures returns the paths of the readable temperature inputs of
+// Device d.
+func temperatures(d *lmsensors.Device) []string {
+	var temps []string
+	for _, s := range d.Sensors {
+		ts, ok := s.(*lmsensors.TemperatureSensor)
+		if !ok || !ts.Validity["input"].OK() {
+			continue
+		}
+
+		temps = append(temps, d.Hwmon+"/"+ts.Name+"_input")
+	}
+
+	return temps
+}
@@ -0,0 +1,262 @@
+package fancontrol
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+func TestCalibrate(t *testing.T) {
+	m := &testMachine{
+		duty: map[string]int{
+			"pwm1": 128,
+			"pwm2": 255,
+		},
+		fans: map[string]*testFan{
+			// Stops below a duty cycle of 60, and only starts again at 100
+			"fan1": {pwm: "pwm1", start: 100, stop: 60, spinning: true},
+			"fan2": {pwm: "pwm2", spinning: true},
+			// Not driven by any PWM output
+			"fan3": {rpm: 900},
+		},
+	}
+
+	ctx := context.Background()
+	cfg, err := calibrate(ctx, m, &testClock{ctx: ctx}, m.control, nil)
+	if err != nil {
+		t.Fatalf("failed to calibrate: %v", err)
+	}
+
+	want := &Config{
+		Interval: 10 * time.Second,
+		DevPath:  map[string]string{"hwmon1": "devices/platform/it87.656"},
+		DevName:  map[string]string{"hwmon1": "it8728"},
+		Outputs: []*Output{
+			{
+				PWM:      "hwmon1/pwm1",
+				Temps:    []string{"hwmon1/temp1_input", "hwmon1/temp2_input"},
+				Fans:     []string{"hwmon1/fan1_input"},
+				MinTemp:  20,
+				MaxTemp:  60,
+				MinStart: 105,
+				MinStop:  65,
+				MaxPWM:   255,
+			},
+			{
+				PWM:      "hwmon1/pwm2",
+				Temps:    []string{"hwmon1/temp1_input", "hwmon1/temp2_input"},
+				Fans:     []string{"hwmon1/fan2_input"},
+				MinTemp:  20,
+				MaxTemp:  60,
+				MinStart: 5,
+				MinStop:  5,
+				MaxPWM:   255,
+			},
+		},
+	}
+
+	// Only compare the ends of each measured curve
+	curves := []struct {
+		first, last CalibrationPoint
+	}{
+		{
+			first: CalibrationPoint{Duty: 55, RPM: []int{0}},
+			last:  CalibrationPoint{Duty: 255, RPM: []int{1530}},
+		},
+		{
+			first: CalibrationPoint{Duty: 5, RPM: []int{30}},
+			last:  CalibrationPoint{Duty: 255, RPM: []int{1530}},
+		},
+	}
+
+	if want, got := len(want.Outputs), len(cfg.Outputs); want != got {
+		t.Fatalf("unexpected number of calibrated outputs: %d != %d", want, got)
+	}
+
+	for i, o := range cfg.Outputs {
+		first, last := o.Curve[0], o.Curve[len(o.Curve)-1]
+		if !reflect.DeepEqual(curves[i].first, first) || !reflect.DeepEqual(curves[i].last, last) {
+			t.Fatalf("unexpected curve for %s:\n- want: %v ... %v\n-  got: %v ... %v",
+				o.PWM, curves[i].first, curves[i].last, first, last)
+		}
+
+		want.Outputs[i].Curve = o.Curve
+	}
+
+	if !reflect.DeepEqual(want, cfg) {
+		t.Fatalf("unexpected Config:\n- want: %#v\n-  got: %#v", want, cfg)
+	}
+
+	// All outputs must be restored to their original state
+	if want, got := map[string]int{"pwm1": 128, "pwm2": 255}, m.duty; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected restored duty cycles:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// The calibration must be usable by the control loop
+	var buf bytes.Buffer
+	if _, err := cfg.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write Config: %v", err)
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse Config: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg, parsed) {
+		t.Fatalf("unexpected parsed Config:\n- want: %#v\n-  got: %#v", cfg, parsed)
+	}
+}
+
+func TestCalibrateFanDoesNotRestart(t *testing.T) {
+	m := &testMachine{
+		duty: map[string]int{"pwm1": 255},
+		fans: map[string]*testFan{
+			// Stops below a duty cycle of 60, and never starts again
+			"fan1": {pwm: "pwm1", start: 256, stop: 60, spinning: true},
+		},
+	}
+
+	ctx := context.Background()
+	if _, err := calibrate(ctx, m, &testClock{ctx: ctx}, m.control, nil); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	if want, got := 255, m.duty["pwm1"]; want != got {
+		t.Fatalf("unexpected restored duty cycle: %d != %d", want, got)
+	}
+}
+
+func TestCalibrateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m := &testMachine{}
+	if _, err := calibrate(ctx, m, &testClock{ctx: ctx}, m.control, nil); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+var _ scanner = &testMachine{}
+
+// A testMachine is a scanner for a single device with PWM outputs which
+// drive emulated fans.
+type testMachine struct {
+	duty map[string]int
+	fans map[string]*testFan
+}
+
+// A testFan is an emulated fan.  A fan driven by a PWM output stops below a
+// duty cycle of stop, and only starts again at a duty cycle of start.
+type testFan struct {
+	pwm         string
+	start, stop int
+	spinning    bool
+
+	// The speed of a fan which is not driven by a PWM output.
+	rpm int
+}
+
+func (m *testMachine) ScanResults() ([]*lmsensors.ScanResult, error) {
+	d := &lmsensors.Device{
+		Name:       "it8728-00",
+		Hwmon:      "hwmon1",
+		DevicePath: "/sys/devices/platform/it87.656",
+		Sensors: []lmsensors.Sensor{
+			&lmsensors.TemperatureSensor{Name: "temp1", Input: 40},
+			&lmsensors.TemperatureSensor{Name: "temp2", Input: 35},
+			&lmsensors.TemperatureSensor{
+				Name: "temp3",
+				Validity: map[string]lmsensors.Validity{
+					"input": {State: lmsensors.StateFaulted},
+				},
+			},
+		},
+	}
+
+	for _, name := range sortedKeys(m.duty) {
+		d.Sensors = append(d.Sensors, &lmsensors.PWMSensor{
+			Name: name,
+			Duty: m.duty[name],
+		})
+	}
+
+	for _, name := range sortedKeys(m.fans) {
+		f := m.fans[name]
+
+		rpm := f.rpm
+		if f.pwm != "" && f.spinning {
+			rpm = m.duty[f.pwm] * 6
+		}
+
+		d.Sensors = append(d.Sensors, &lmsensors.FanSensor{
+			Name:  name,
+			Input: rpm,
+		})
+	}
+
+	return []*lmsensors.ScanResult{{Device: d}}, nil
+}
+
+// control takes control of an emulated PWM output.
+func (m *testMachine) control(_ *lmsensors.Device, s *lmsensors.PWMSensor) (pwmControl, error) {
+	return &testMachinePWM{
+		m:    m,
+		name: s.Name,
+		orig: m.duty[s.Name],
+	}, nil
+}
+
+var _ pwmControl = &testMachinePWM{}
+
+// A testMachinePWM is a pwmControl for an emulated PWM output.
+type testMachinePWM struct {
+	m    *testMachine
+	name string
+	orig int
+}
+
+func (p *testMachinePWM) Set(duty int) (int, error) {
+	p.m.duty[p.name] = duty
+
+	for _, f := range p.m.fans {
+		if f.pwm != p.name {
+			continue
+		}
+
+		switch {
+		case duty < f.stop:
+			f.spinning = false
+		case duty >= f.start:
+			f.spinning = true
+		}
+	}
+
+	return duty, nil
+}
+
+func (p *testMachinePWM) FullSpeed() error {
+	_, err := p.Set(255)
+	return err
+}
+
+func (p *testMachinePWM) Close() error {
+	_, err := p.Set(p.orig)
+	return err
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,355 @@
+package fancontrol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Config is a fancontrol configuration, typically read from
+// /etc/fancontrol and generated by pwmconfig.
+//
+// Sensor attributes are identified by their path relative to
+// /sys/class/hwmon, such as "hwmon1/temp1_input".
+type Config struct {
+	// The interval between updates of the fan speeds (INTERVAL).
+	Interval time.Duration
+
+	// The path of the device backing each hwmon directory, relative to
+	// /sys, such as "devices/platform/it87.656" (DEVPATH).
+	DevPath map[string]string
+
+	// The chip name of each hwmon directory, such as "it8728" (DEVNAME).
+	DevName map[string]string
+
+	// The PWM outputs under control, in configuration order.
+	Outputs []*Output
+}
+
+// An Output is the configuration for a single PWM output.
+type Output struct {
+	// The PWM output, such as "hwmon1/pwm1".
+	PWM string
+
+	// The temperature inputs which drive the output (FCTEMPS).  If more
+	// than one is specified, the highest temperature is used.
+	Temps []string
+
+	// The fan speed inputs driven by the output (FCFANS).
+	Fans []string
+
+	// The temperature in degrees Celsius at or below which the output
+	// runs at MinPWM (MINTEMP).
+	MinTemp float64
+
+	// The temperature in degrees Celsius at or above which the output
+	// runs at MaxPWM (MAXTEMP).
+	MaxTemp float64
+
+	// The duty cycle used to start a stopped fan (MINSTART).
+	MinStart int
+
+	// The lowest duty cycle at which the fan keeps spinning (MINSTOP).
+	MinStop int
+
+	// The duty cycle used at or below MinTemp (MINPWM).
+	MinPWM int
+
+	// The duty cycle used at or above MaxTemp (MAXPWM).
+	MaxPWM int
+
+	// The speed of each fan at each duty cycle measured by Calibrate, in
+	// order of increasing duty cycle (CURVE).  The curve is not used to
+	// control the output.
+	Curve []CalibrationPoint
+}
+
+// Parse parses a fancontrol configuration from r.
+func Parse(r io.Reader) (*Config, error) {
+	c := &Config{
+		DevPath: make(map[string]string),
+		DevName: make(map[string]string),
+	}
+
+	// Per-output values, keyed by variable name and then PWM output.
+	vars := make(map[string]map[string]string)
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("fancontrol: line %d: expected VARIABLE=value: %q", n, line)
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		if key == "INTERVAL" {
+			i, err := strconv.Atoi(value)
+			if err != nil || i <= 0 {
+				return nil, fmt.Errorf("fancontrol: line %d: invalid INTERVAL %q", n, value)
+			}
+
+			c.Interval = time.Duration(i) * time.Second
+			continue
+		}
+
+		pairs := make(map[string]string)
+		for _, f := range strings.Fields(value) {
+			p := strings.SplitN(f, "=", 2)
+			if len(p) != 2 || p[0] == "" {
+				return nil, fmt.Errorf("fancontrol: line %d: expected path=value in %s: %q", n, key, f)
+			}
+
+			pairs[p[0]] = p[1]
+		}
+
+		switch key {
+		case "DEVPATH":
+			c.DevPath = pairs
+		case "DEVNAME":
+			c.DevName = pairs
+		case "FCTEMPS":
+			// Preserve the order of outputs as they appear in FCTEMPS
+			for _, f := range strings.Fields(value) {
+				c.Outputs = append(c.Outputs, &Output{
+					PWM: strings.SplitN(f, "=", 2)[0],
+				})
+			}
+
+			vars[key] = pairs
+		default:
+			// Unknown variables are ignored, as they are by fancontrol
+			vars[key] = pairs
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.Interval == 0 {
+		return nil, fmt.Errorf("fancontrol: INTERVAL must be set")
+	}
+	if len(c.Outputs) == 0 {
+		return nil, fmt.Errorf("fancontrol: FCTEMPS must specify at least one PWM output")
+	}
+
+	for _, o := range c.Outputs {
+		if err := o.parse(vars); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// parse populates the Output from the per-output variables in vars.
+func (o *Output) parse(vars map[string]map[string]string) error {
+	o.Temps = splitSources(vars["FCTEMPS"][o.PWM])
+	if len(o.Temps) == 0 {
+		return fmt.Errorf("fancontrol: no temperature input for %s in FCTEMPS", o.PWM)
+	}
+
+	o.Fans = splitSources(vars["FCFANS"][o.PWM])
+
+	ints := []struct {
+		key      string
+		v        *int
+		optional bool
+		def      int
+	}{
+		{key: "MINSTART", v: &o.MinStart},
+		{key: "MINSTOP", v: &o.MinStop},
+		{key: "MINPWM", v: &o.MinPWM, optional: true},
+		{key: "MAXPWM", v: &o.MaxPWM, optional: true, def: 255},
+	}
+
+	for _, i := range ints {
+		s, ok := vars[i.key][o.PWM]
+		if !ok {
+			if !i.optional {
+				return fmt.Errorf("fancontrol: no %s value for %s", i.key, o.PWM)
+			}
+
+			*i.v = i.def
+			continue
+		}
+
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 || v > 255 {
+			return fmt.Errorf("fancontrol: invalid %s value for %s: %q", i.key, o.PWM, s)
+		}
+
+		*i.v = v
+	}
+
+	temps := []struct {
+		key string
+		v   *float64
+	}{
+		{key: "MINTEMP", v: &o.MinTemp},
+		{key: "MAXTEMP", v: &o.MaxTemp},
+	}
+
+	for _, t := range temps {
+		s, ok := vars[t.key][o.PWM]
+		if !ok {
+			return fmt.Errorf("fancontrol: no %s value for %s", t.key, o.PWM)
+		}
+
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("fancontrol: invalid %s value for %s: %q", t.key, o.PWM, s)
+		}
+
+		*t.v = v
+	}
+
+	if curve, ok := vars["CURVE"][o.PWM]; ok {
+		if err := o.parseCurve(curve); err != nil {
+			return err
+		}
+	}
+
+	// Apply the same sanity checks as fancontrol
+	switch {
+	case o.MinTemp >= o.MaxTemp:
+		return fmt.Errorf("fancontrol: MINTEMP must be less than MAXTEMP for %s", o.PWM)
+	case o.MinStop >= o.MaxPWM:
+		return fmt.Errorf("fancontrol: MINSTOP must be less than MAXPWM for %s", o.PWM)
+	case o.MinPWM > o.MinStop:
+		return fmt.Errorf("fancontrol: MINPWM must be less than or equal to MINSTOP for %s", o.PWM)
+	}
+
+	return nil
+}
+
+// parseCurve parses the CURVE value of the Output, a comma-separated list
+// of duty cycles and the speed of each fan, such as "55:0,65:390+420".
+func (o *Output) parseCurve(curve string) error {
+	for _, p := range strings.Split(curve, ",") {
+		dr := strings.SplitN(p, ":", 2)
+		if len(dr) != 2 {
+			return fmt.Errorf("fancontrol: invalid CURVE point for %s: %q", o.PWM, p)
+		}
+
+		duty, err := strconv.Atoi(dr[0])
+		if err != nil {
+			return fmt.Errorf("fancontrol: invalid CURVE point for %s: %q", o.PWM, p)
+		}
+
+		point := CalibrationPoint{Duty: duty}
+		for _, s := range strings.Split(dr[1], "+") {
+			rpm, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("fancontrol: invalid CURVE point for %s: %q", o.PWM, p)
+			}
+
+			point.RPM = append(point.RPM, rpm)
+		}
+
+		if len(point.RPM) != len(o.Fans) {
+			return fmt.Errorf("fancontrol: CURVE point for %s has %d fan speeds, but %d fans: %q",
+				o.PWM, len(point.RPM), len(o.Fans), p)
+		}
+
+		o.Curve = append(o.Curve, point)
+	}
+
+	return nil
+}
+
+// WriteTo writes the Config to w in the fancontrol configuration format,
+// as pwmconfig does.  Output curves are written to the CURVE variable,
+// which is ignored by fancontrol.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("# Configuration file generated by lmsensors\n")
+
+	fmt.Fprintf(&b, "INTERVAL=%d\n", int(c.Interval/time.Second))
+
+	pairs := func(key string, m map[string]string) {
+		if len(m) == 0 {
+			return
+		}
+
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		ss := make([]string, 0, len(keys))
+		for _, k := range keys {
+			ss = append(ss, k+"="+m[k])
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", key, strings.Join(ss, " "))
+	}
+
+	// Outputs with no value for a variable are omitted from its line
+	outputs := func(key string, fn func(o *Output) string) {
+		var ss []string
+		for _, o := range c.Outputs {
+			if v := fn(o); v != "" {
+				ss = append(ss, o.PWM+"="+v)
+			}
+		}
+
+		if len(ss) > 0 {
+			fmt.Fprintf(&b, "%s=%s\n", key, strings.Join(ss, " "))
+		}
+	}
+
+	itoa := func(fn func(o *Output) int) func(o *Output) string {
+		return func(o *Output) string { return strconv.Itoa(fn(o)) }
+	}
+	ftoa := func(fn func(o *Output) float64) func(o *Output) string {
+		return func(o *Output) string { return strconv.FormatFloat(fn(o), 'f', -1, 64) }
+	}
+
+	pairs("DEVPATH", c.DevPath)
+	pairs("DEVNAME", c.DevName)
+
+	outputs("FCTEMPS", func(o *Output) string { return strings.Join(o.Temps, "+") })
+	outputs("FCFANS", func(o *Output) string { return strings.Join(o.Fans, "+") })
+	outputs("MINTEMP", ftoa(func(o *Output) float64 { return o.MinTemp }))
+	outputs("MAXTEMP", ftoa(func(o *Output) float64 { return o.MaxTemp }))
+	outputs("MINSTART", itoa(func(o *Output) int { return o.MinStart }))
+	outputs("MINSTOP", itoa(func(o *Output) int { return o.MinStop }))
+	outputs("MINPWM", itoa(func(o *Output) int { return o.MinPWM }))
+	outputs("MAXPWM", itoa(func(o *Output) int { return o.MaxPWM }))
+	outputs("CURVE", func(o *Output) string {
+		ss := make([]string, 0, len(o.Curve))
+		for _, p := range o.Curve {
+			rpms := make([]string, 0, len(p.RPM))
+			for _, rpm := range p.RPM {
+				rpms = append(rpms, strconv.Itoa(rpm))
+			}
+
+			ss = append(ss, strconv.Itoa(p.Duty)+":"+strings.Join(rpms, "+"))
+		}
+
+		return strings.Join(ss, ",")
+	})
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// splitSources splits a list of sensor attributes joined by "+".
+func splitSources(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "+")
+}
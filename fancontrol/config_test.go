@@ -0,0 +1,112 @@
+package fancontrol
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		c    *Config
+		ok   bool
+	}{
+		{
+			name: "pwmconfig output",
+			s: `# Configuration file generated by pwmconfig, changes will be lost
+INTERVAL=10
+DEVPATH=hwmon1=devices/platform/it87.656
+DEVNAME=hwmon1=it8728
+FCTEMPS=hwmon1/pwm2=hwmon1/temp2_input hwmon1/pwm1=hwmon1/temp1_input+hwmon1/temp3_input
+FCFANS=hwmon1/pwm2=hwmon1/fan2_input hwmon1/pwm1=hwmon1/fan1_input
+MINTEMP=hwmon1/pwm2=40 hwmon1/pwm1=35
+MAXTEMP=hwmon1/pwm2=70 hwmon1/pwm1=60
+MINSTART=hwmon1/pwm2=150 hwmon1/pwm1=120
+MINSTOP=hwmon1/pwm2=100 hwmon1/pwm1=80
+MINPWM=hwmon1/pwm1=20
+MAXPWM=hwmon1/pwm2=200
+`,
+			c: &Config{
+				Interval: 10 * time.Second,
+				DevPath:  map[string]string{"hwmon1": "devices/platform/it87.656"},
+				DevName:  map[string]string{"hwmon1": "it8728"},
+				Outputs: []*Output{
+					{
+						PWM:      "hwmon1/pwm2",
+						Temps:    []string{"hwmon1/temp2_input"},
+						Fans:     []string{"hwmon1/fan2_input"},
+						MinTemp:  40,
+						MaxTemp:  70,
+						MinStart: 150,
+						MinStop:  100,
+						MinPWM:   0,
+						MaxPWM:   200,
+					},
+					{
+						PWM:      "hwmon1/pwm1",
+						Temps:    []string{"hwmon1/temp1_input", "hwmon1/temp3_input"},
+						Fans:     []string{"hwmon1/fan1_input"},
+						MinTemp:  35,
+						MaxTemp:  60,
+						MinStart: 120,
+						MinStop:  80,
+						MinPWM:   20,
+						MaxPWM:   255,
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			name: "no interval",
+			s: `FCTEMPS=hwmon1/pwm1=hwmon1/temp1_input
+MINTEMP=hwmon1/pwm1=35
+MAXTEMP=hwmon1/pwm1=60
+MINSTART=hwmon1/pwm1=120
+MINSTOP=hwmon1/pwm1=80
+`,
+		},
+		{
+			name: "missing MINSTOP",
+			s: `INTERVAL=10
+FCTEMPS=hwmon1/pwm1=hwmon1/temp1_input
+MINTEMP=hwmon1/pwm1=35
+MAXTEMP=hwmon1/pwm1=60
+MINSTART=hwmon1/pwm1=120
+`,
+		},
+		{
+			name: "MINTEMP above MAXTEMP",
+			s: `INTERVAL=10
+FCTEMPS=hwmon1/pwm1=hwmon1/temp1_input
+MINTEMP=hwmon1/pwm1=70
+MAXTEMP=hwmon1/pwm1=60
+MINSTART=hwmon1/pwm1=120
+MINSTOP=hwmon1/pwm1=80
+`,
+		},
+		{
+			name: "malformed line",
+			s:    "INTERVAL 10\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(tt.s))
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !tt.ok {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := tt.c, c; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Config:\n- want: %#v\n-  got: %#v", want, got)
+			}
+		})
+	}
+}
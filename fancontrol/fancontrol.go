@@ -0,0 +1,486 @@
+// Package fancontrol implements a fan speed control daemon which is
+// compatible with the lm-sensors fancontrol script and its configuration
+// file format.
+package fancontrol
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// A Controller adjusts fan speeds in response to temperatures, using the
+// same control algorithm as fancontrol.
+type Controller struct {
+	cfg     *Config
+	s       scanner
+	clock   clock
+	control controlFunc
+
+	// refs holds the hwmon names of the devices referenced by cfg.
+	refs map[string]bool
+
+	// outputs holds the running state of each configured output.
+	outputs []*output
+}
+
+// A scanner scans for Devices, used for testing.
+type scanner interface {
+	ScanResults() ([]*lmsensors.ScanResult, error)
+}
+
+// A clock is an interface to the system clock, used for testing.
+type clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// A pwmControl manually controls a PWM output, used for testing.
+type pwmControl interface {
+	Set(duty int) (int, error)
+	FullSpeed() error
+	Close() error
+}
+
+// A controlFunc takes manual control of a PWM output.
+type controlFunc func(d *lmsensors.Device, s *lmsensors.PWMSensor) (pwmControl, error)
+
+// An output is the running state of an Output.
+type output struct {
+	cfg *Output
+
+	d    *lmsensors.Device
+	s    *lmsensors.PWMSensor
+	ctrl pwmControl
+
+	// The previously applied duty cycle.
+	duty int
+}
+
+// New creates a Controller for the configuration cfg, using s to read
+// sensors.  New verifies that the devices described by DEVPATH and DEVNAME
+// still match the devices found by s, and that every configured sensor
+// attribute exists.
+//
+// Errors which occur while scanning devices that are not referenced by cfg
+// are ignored, both by New and while the Controller runs.
+func New(cfg *Config, s *lmsensors.Scanner) (*Controller, error) {
+	return newController(cfg, s, &systemClock{}, controlPWM)
+}
+
+// newController creates a Controller with the specified scanner, clock and
+// means of controlling PWM outputs.
+func newController(cfg *Config, s scanner, clock clock, control controlFunc) (*Controller, error) {
+	c := &Controller{
+		cfg:     cfg,
+		s:       s,
+		clock:   clock,
+		control: control,
+		refs:    references(cfg),
+	}
+
+	devices, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDevices(cfg, devices); err != nil {
+		return nil, err
+	}
+
+	for _, o := range cfg.Outputs {
+		pwm, err := parseSource(o.PWM)
+		if err != nil {
+			return nil, err
+		}
+
+		d, ps, err := findPWM(devices, pwm)
+		if err != nil {
+			return nil, err
+		}
+
+		// Verify that all inputs exist before taking control of any fans
+		for _, t := range o.Temps {
+			if _, err := findTemperature(devices, t); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range o.Fans {
+			if _, err := findFan(devices, f); err != nil {
+				return nil, err
+			}
+		}
+
+		c.outputs = append(c.outputs, &output{
+			cfg:  o,
+			d:    d,
+			s:    ps,
+			duty: ps.Duty,
+		})
+	}
+
+	return c, nil
+}
+
+// Run takes manual control of all configured PWM outputs and adjusts them
+// every Interval until ctx is canceled.  When Run returns, all outputs are
+// set to full speed.
+func (c *Controller) Run(ctx context.Context) (err error) {
+	defer func() {
+		// Always fall back to full speed, so a stopped controller cannot
+		// leave fans running too slowly to cool the machine
+		for _, o := range c.outputs {
+			if o.ctrl == nil {
+				continue
+			}
+
+			if ferr := o.ctrl.FullSpeed(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}()
+
+	for _, o := range c.outputs {
+		ctrl, err := c.control(o.d, o.s)
+		if err != nil {
+			return err
+		}
+
+		o.ctrl = ctrl
+	}
+
+	for {
+		if err := c.update(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.clock.After(c.cfg.Interval):
+		}
+	}
+}
+
+// update reads all sensors and applies a new duty cycle to each output.
+func (c *Controller) update(ctx context.Context) error {
+	devices, err := c.scan()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range c.outputs {
+		// If any temperature cannot be read, run the fan at full speed
+		// until it can be read again
+		temp, ok := 0.0, true
+		for i, src := range o.cfg.Temps {
+			t, err := readTemperature(devices, src)
+			if err != nil {
+				ok = false
+				break
+			}
+
+			if i == 0 || t > temp {
+				temp = t
+			}
+		}
+
+		if !ok {
+			if err := o.set(255); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Determine whether or not any of the fans have stopped
+		stopped := false
+		for _, src := range o.cfg.Fans {
+			rpm, err := readFan(devices, src)
+			if err == nil && rpm == 0 {
+				stopped = true
+			}
+		}
+
+		duty, start := o.cfg.duty(temp)
+		if start && (o.duty == o.cfg.MinPWM || stopped) {
+			// Fans which were held at the minimum duty cycle, or which have
+			// stopped, need a higher duty cycle to start spinning again
+			if err := o.set(o.cfg.MinStart); err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-c.clock.After(1 * time.Second):
+			}
+		}
+
+		if err := o.set(duty); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scan scans for Devices.  Errors which occur while scanning Devices that
+// are not referenced by the configuration are ignored, so an unrelated
+// failing sensor cannot stop fan control.
+func (c *Controller) scan() ([]*lmsensors.Device, error) {
+	results, err := c.s.ScanResults()
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*lmsensors.Device, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			if c.refs[r.Device.Hwmon] {
+				return nil, r.Err
+			}
+
+			continue
+		}
+
+		devices = append(devices, r.Device)
+	}
+
+	return devices, nil
+}
+
+// set applies duty cycle duty to the output.
+func (o *output) set(duty int) error {
+	out, err := o.ctrl.Set(duty)
+	if err != nil {
+		return err
+	}
+
+	o.duty = out
+	return nil
+}
+
+// duty computes the duty cycle for the Output at temperature temp, as
+// fancontrol does.  If the fan must be spinning at the computed duty cycle,
+// duty also returns true.
+func (o *Output) duty(temp float64) (int, bool) {
+	switch {
+	case temp <= o.MinTemp:
+		return o.MinPWM, false
+	case temp >= o.MaxTemp:
+		return o.MaxPWM, false
+	}
+
+	// Scale linearly between the lowest duty cycle which keeps the fan
+	// spinning and the maximum duty cycle
+	d := (temp-o.MinTemp)*float64(o.MaxPWM-o.MinStop)/(o.MaxTemp-o.MinTemp) + float64(o.MinStop)
+	return int(d), true
+}
+
+// checkDevices verifies that the DEVPATH and DEVNAME values in cfg match
+// the scanned devices, so that a configuration is not applied to the wrong
+// hardware if hwmon devices are renumbered.
+func checkDevices(cfg *Config, devices []*lmsensors.Device) error {
+	for hwmon, want := range cfg.DevPath {
+		d := findDevice(devices, hwmon)
+		if d == nil {
+			return fmt.Errorf("fancontrol: device %s not found", hwmon)
+		}
+
+		if got := strings.TrimPrefix(d.DevicePath, "/sys/"); got != want {
+			return fmt.Errorf("fancontrol: device path of %s is %q, but configuration expects %q; run pwmconfig again",
+				hwmon, got, want)
+		}
+	}
+
+	for hwmon, want := range cfg.DevName {
+		d := findDevice(devices, hwmon)
+		if d == nil {
+			return fmt.Errorf("fancontrol: device %s not found", hwmon)
+		}
+
+		if got := chipName(d); got != want {
+			return fmt.Errorf("fancontrol: device name of %s is %q, but configuration expects %q; run pwmconfig again",
+				hwmon, got, want)
+		}
+	}
+
+	return nil
+}
+
+// chipName returns the name of a Device as reported by its driver, without
+// the numeric suffix added by the Scanner.
+func chipName(d *lmsensors.Device) string {
+	i := strings.LastIndex(d.Name, "-")
+	if i == -1 {
+		return d.Name
+	}
+
+	return d.Name[:i]
+}
+
+// references returns the hwmon names of all devices referenced by cfg.
+func references(cfg *Config) map[string]bool {
+	refs := make(map[string]bool)
+	for hwmon := range cfg.DevPath {
+		refs[hwmon] = true
+	}
+	for hwmon := range cfg.DevName {
+		refs[hwmon] = true
+	}
+
+	for _, o := range cfg.Outputs {
+		paths := append([]string{o.PWM}, o.Temps...)
+		paths = append(paths, o.Fans...)
+
+		// Invalid paths are reported when the Controller is created
+		for _, p := range paths {
+			if src, err := parseSource(p); err == nil {
+				refs[src.hwmon] = true
+			}
+		}
+	}
+
+	return refs
+}
+
+// A source identifies a sensor attribute in a fancontrol configuration.
+type source struct {
+	hwmon  string
+	sensor string
+	attr   string
+}
+
+// parseSource parses a source from a path relative to /sys/class/hwmon,
+// such as "hwmon1/temp1_input" or "hwmon1/device/pwm1".
+func parseSource(s string) (source, error) {
+	// Older kernels exposed attributes on the underlying device
+	dir, file := path.Split(strings.Replace(s, "/device/", "/", 1))
+	dir = strings.TrimSuffix(dir, "/")
+
+	if dir == "" || strings.Contains(dir, "/") || file == "" {
+		return source{}, fmt.Errorf("fancontrol: invalid sensor path %q", s)
+	}
+
+	src := source{
+		hwmon:  dir,
+		sensor: file,
+	}
+
+	if i := strings.Index(file, "_"); i != -1 {
+		src.sensor, src.attr = file[:i], file[i+1:]
+	}
+
+	return src, nil
+}
+
+// findDevice finds the Device with the specified hwmon name.
+func findDevice(devices []*lmsensors.Device, hwmon string) *lmsensors.Device {
+	for _, d := range devices {
+		if d.Hwmon == hwmon {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// findPWM finds the PWMSensor identified by src.
+func findPWM(devices []*lmsensors.Device, src source) (*lmsensors.Device, *lmsensors.PWMSensor, error) {
+	if d := findDevice(devices, src.hwmon); d != nil {
+		for _, s := range d.Sensors {
+			if s, ok := s.(*lmsensors.PWMSensor); ok && s.Name == src.sensor {
+				return d, s, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("fancontrol: PWM output %s/%s not found", src.hwmon, src.sensor)
+}
+
+// readTemperature reads the temperature in degrees Celsius from the
+// attribute identified by path.
+func readTemperature(devices []*lmsensors.Device, path string) (float64, error) {
+	s, err := findTemperature(devices, path)
+	if err != nil {
+		return 0, err
+	}
+
+	if v := s.Validity["input"]; !v.OK() {
+		return 0, fmt.Errorf("fancontrol: temperature %s is invalid: %v", path, v.State)
+	}
+
+	return s.Input, nil
+}
+
+// readFan reads the fan speed in rotations per minute from the attribute
+// identified by path.
+func readFan(devices []*lmsensors.Device, path string) (int, error) {
+	s, err := findFan(devices, path)
+	if err != nil {
+		return 0, err
+	}
+
+	if v := s.Validity["input"]; !v.OK() {
+		return 0, fmt.Errorf("fancontrol: fan %s is invalid: %v", path, v.State)
+	}
+
+	return s.Input, nil
+}
+
+// findTemperature finds the TemperatureSensor identified by path.
+func findTemperature(devices []*lmsensors.Device, path string) (*lmsensors.TemperatureSensor, error) {
+	src, err := parseSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if d := findDevice(devices, src.hwmon); d != nil {
+		for _, s := range d.Sensors {
+			if s, ok := s.(*lmsensors.TemperatureSensor); ok && s.Name == src.sensor {
+				return s, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("fancontrol: temperature %s not found", path)
+}
+
+// findFan finds the FanSensor identified by path.
+func findFan(devices []*lmsensors.Device, path string) (*lmsensors.FanSensor, error) {
+	src, err := parseSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if d := findDevice(devices, src.hwmon); d != nil {
+		for _, s := range d.Sensors {
+			if s, ok := s.(*lmsensors.FanSensor); ok && s.Name == src.sensor {
+				return s, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("fancontrol: fan %s not found", path)
+}
+
+// controlPWM takes manual control of PWM output s on Device d.
+func controlPWM(d *lmsensors.Device, s *lmsensors.PWMSensor) (pwmControl, error) {
+	c, err := d.ControlPWM(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+var _ clock = &systemClock{}
+
+// A systemClock is a clock which uses the host's system clock.
+type systemClock struct{}
+
+func (c *systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
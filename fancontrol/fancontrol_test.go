@@ -0,0 +1,400 @@
+package fancontrol
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+func TestOutputDuty(t *testing.T) {
+	o := &Output{
+		MinTemp: 40,
+		MaxTemp: 60,
+		MinStop: 100,
+		MinPWM:  0,
+		MaxPWM:  200,
+	}
+
+	tests := []struct {
+		temp  float64
+		duty  int
+		start bool
+	}{
+		{temp: 30, duty: 0},
+		{temp: 40, duty: 0},
+		{temp: 45, duty: 125, start: true},
+		{temp: 50, duty: 150, start: true},
+		{temp: 60, duty: 200},
+		{temp: 90, duty: 200},
+	}
+
+	for _, tt := range tests {
+		duty, start := o.duty(tt.temp)
+		if duty != tt.duty || start != tt.start {
+			t.Fatalf("unexpected duty cycle at %v degrees:\n- want: %d, %v\n-  got: %d, %v",
+				tt.temp, tt.duty, tt.start, duty, start)
+		}
+	}
+}
+
+func TestCheckDevices(t *testing.T) {
+	devices := []*lmsensors.Device{
+		{
+			Name:       "it8728-00",
+			Hwmon:      "hwmon1",
+			DevicePath: "/sys/devices/platform/it87.656",
+		},
+		{
+			Name:  "acpitz-00",
+			Hwmon: "hwmon0",
+		},
+	}
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		ok   bool
+	}{
+		{
+			name: "match",
+			cfg: &Config{
+				DevPath: map[string]string{"hwmon1": "devices/platform/it87.656"},
+				DevName: map[string]string{"hwmon1": "it8728", "hwmon0": "acpitz"},
+			},
+			ok: true,
+		},
+		{
+			name: "renumbered",
+			cfg: &Config{
+				DevPath: map[string]string{"hwmon0": "devices/platform/it87.656"},
+			},
+		},
+		{
+			name: "different chip",
+			cfg: &Config{
+				DevName: map[string]string{"hwmon1": "nct6775"},
+			},
+		},
+		{
+			name: "missing device",
+			cfg: &Config{
+				DevName: map[string]string{"hwmon2": "it8728"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDevices(tt.cfg, devices)
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !tt.ok {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		s   string
+		src source
+		ok  bool
+	}{
+		{
+			s:   "hwmon1/temp1_input",
+			src: source{hwmon: "hwmon1", sensor: "temp1", attr: "input"},
+			ok:  true,
+		},
+		{
+			s:   "hwmon1/device/pwm1",
+			src: source{hwmon: "hwmon1", sensor: "pwm1"},
+			ok:  true,
+		},
+		{s: "temp1_input"},
+		{s: "hwmon1/"},
+	}
+
+	for _, tt := range tests {
+		src, err := parseSource(tt.s)
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error for %q: %v", tt.s, err)
+		}
+		if err == nil && !tt.ok {
+			t.Fatalf("expected an error for %q, but none occurred", tt.s)
+		}
+
+		if src != tt.src {
+			t.Fatalf("unexpected source for %q:\n- want: %#v\n-  got: %#v", tt.s, tt.src, src)
+		}
+	}
+}
+
+func TestControllerRun(t *testing.T) {
+	const interval = 10 * time.Second
+
+	tests := []struct {
+		name   string
+		minPWM int
+		scans  []testScan
+		duties []int
+		afters []time.Duration
+		ok     bool
+	}{
+		{
+			name: "control loop",
+			scans: []testScan{
+				{temp: 30, rpm: 0},
+				{temp: 30, rpm: 0},
+				// A stopped fan is started with MINSTART
+				{temp: 50, rpm: 0},
+				{temp: 50, rpm: 900},
+				{temp: 70, rpm: 1500},
+			},
+			duties: []int{0, 150, 177, 177, 255},
+			afters: []time.Duration{interval, time.Second, interval, interval, interval},
+			ok:     true,
+		},
+		{
+			name:   "start from MINPWM",
+			minPWM: 80,
+			scans: []testScan{
+				{temp: 30, rpm: 600},
+				{temp: 30, rpm: 600},
+				// The fan is still spinning, but was held at MINPWM
+				{temp: 50, rpm: 600},
+				{temp: 50, rpm: 900},
+			},
+			duties: []int{80, 150, 177, 177},
+			afters: []time.Duration{interval, time.Second, interval, interval},
+			ok:     true,
+		},
+		{
+			name: "invalid temperature",
+			scans: []testScan{
+				{temp: 50, rpm: 900},
+				{temp: 50, rpm: 900, invalid: true},
+				{temp: 50, rpm: 900},
+			},
+			duties: []int{255, 177},
+			afters: []time.Duration{interval, interval},
+			ok:     true,
+		},
+		{
+			name: "unreferenced device error",
+			scans: []testScan{
+				{temp: 50, rpm: 900, otherErr: true},
+				{temp: 50, rpm: 900, otherErr: true},
+			},
+			duties: []int{177},
+			afters: []time.Duration{interval},
+			ok:     true,
+		},
+		{
+			name: "referenced device error",
+			scans: []testScan{
+				{temp: 50, rpm: 900},
+				{temp: 50, rpm: 900},
+				{temp: 50, rpm: 900, deviceErr: true},
+			},
+			duties: []int{177},
+			afters: []time.Duration{interval},
+		},
+		{
+			name: "scan error",
+			scans: []testScan{
+				{temp: 50, rpm: 900},
+				{temp: 50, rpm: 900},
+				{scanErr: true},
+			},
+			duties: []int{177},
+			afters: []time.Duration{interval},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Interval: interval,
+				DevName:  map[string]string{"hwmon1": "it8728"},
+				Outputs: []*Output{{
+					PWM:      "hwmon1/pwm1",
+					Temps:    []string{"hwmon1/temp1_input"},
+					Fans:     []string{"hwmon1/fan1_input"},
+					MinTemp:  40,
+					MaxTemp:  60,
+					MinStart: 150,
+					MinStop:  100,
+					MinPWM:   tt.minPWM,
+					MaxPWM:   255,
+				}},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The first scan is used to create the Controller, and the
+			// Controller stops after the last scan
+			s := &testScanner{
+				scans: tt.scans,
+				done:  cancel,
+			}
+			clock := &testClock{ctx: ctx}
+			ctrl := &testControl{}
+
+			c, err := newController(cfg, s, clock, func(_ *lmsensors.Device, _ *lmsensors.PWMSensor) (pwmControl, error) {
+				return ctrl, nil
+			})
+			if err != nil {
+				t.Fatalf("failed to create controller: %v", err)
+			}
+
+			err = c.Run(ctx)
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !tt.ok {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if want, got := tt.duties, ctrl.duties; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected duty cycles:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.afters, clock.afters; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected waits:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			// Outputs always fall back to full speed when Run returns
+			if !ctrl.full {
+				t.Fatal("output was not set to full speed")
+			}
+		})
+	}
+}
+
+// A testScan describes the sensor readings returned by a single scan of a
+// testScanner.
+type testScan struct {
+	temp    float64
+	rpm     int
+	invalid bool
+
+	// Errors for the scan, the configured device, or an unrelated device.
+	scanErr   bool
+	deviceErr bool
+	otherErr  bool
+}
+
+var _ scanner = &testScanner{}
+
+// A testScanner is a scanner which returns a fixed sequence of scans.
+type testScanner struct {
+	scans []testScan
+	done  func()
+}
+
+func (s *testScanner) ScanResults() ([]*lmsensors.ScanResult, error) {
+	if len(s.scans) == 0 {
+		return nil, errors.New("no more scans")
+	}
+
+	scan := s.scans[0]
+	s.scans = s.scans[1:]
+	if len(s.scans) == 0 {
+		s.done()
+	}
+
+	if scan.scanErr {
+		return nil, errors.New("scan failed")
+	}
+
+	temp := &lmsensors.TemperatureSensor{
+		Name:  "temp1",
+		Input: scan.temp,
+	}
+	if scan.invalid {
+		temp.Validity = map[string]lmsensors.Validity{
+			"input": {State: lmsensors.StateIOError},
+		}
+	}
+
+	results := []*lmsensors.ScanResult{{
+		Device: &lmsensors.Device{
+			Name:  "it8728-00",
+			Hwmon: "hwmon1",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.FanSensor{
+					Name:  "fan1",
+					Input: scan.rpm,
+				},
+				&lmsensors.PWMSensor{
+					Name:   "pwm1",
+					Enable: lmsensors.PWMEnableAutomatic,
+					Duty:   128,
+				},
+				temp,
+			},
+		},
+	}}
+
+	if scan.deviceErr {
+		results[0].Err = errors.New("device failed")
+	}
+
+	if scan.otherErr {
+		results = append(results, &lmsensors.ScanResult{
+			Device: &lmsensors.Device{
+				Name:  "nvme-00",
+				Hwmon: "hwmon2",
+			},
+			Err: errors.New("device failed"),
+		})
+	}
+
+	return results, nil
+}
+
+var _ clock = &testClock{}
+
+// A testClock is a clock whose timers fire immediately until ctx is
+// canceled, and then never fire.
+type testClock struct {
+	ctx    context.Context
+	afters []time.Duration
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.afters = append(c.afters, d)
+	if c.ctx.Err() != nil {
+		return nil
+	}
+
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+var _ pwmControl = &testControl{}
+
+// A testControl is a pwmControl which records the duty cycles it is set to.
+type testControl struct {
+	duties []int
+	full   bool
+}
+
+func (c *testControl) Set(duty int) (int, error) {
+	c.duties = append(c.duties, duty)
+	return duty, nil
+}
+
+func (c *testControl) FullSpeed() error {
+	c.full = true
+	return nil
+}
+
+func (c *testControl) Close() error { return nil }
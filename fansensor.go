@@ -11,6 +11,10 @@ type FanSensor struct {
 	// The name of the sensor.
 	Name string
 
+	// A label that describes what the sensor is monitoring.  Label may be
+	// empty.
+	Label string
+
 	// Whether or not the fan speed is below the minimum threshold.
 	Alarm bool
 
@@ -24,10 +28,25 @@ type FanSensor struct {
 	// The low threshold fan speed, in rotations per minute, indicated by the
 	// sensor.
 	Minimum int
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
+
+	// The optional attributes provided by the sensor's device, such as "min"
+	// or "alarm", keyed by attribute name.  Optional attributes which are
+	// present are reported even if their values are zero.  If nil, limits are
+	// only reported if their values are nonzero.
+	Attributes map[string]bool
 }
 
-func (s *FanSensor) name() string        { return s.Name }
-func (s *FanSensor) setName(name string) { s.Name = name }
+func (s *FanSensor) name() string                      { return s.Name }
+func (s *FanSensor) setName(name string)               { s.Name = name }
+func (s *FanSensor) validity() map[string]Validity     { return s.Validity }
+func (s *FanSensor) setValidity(v map[string]Validity) { s.Validity = v }
+func (s *FanSensor) attributes() map[string]bool       { return s.Attributes }
+func (s *FanSensor) setAttributes(a map[string]bool)   { s.Attributes = a }
 
 func (s *FanSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
@@ -43,11 +62,21 @@ func (s *FanSensor) parse(raw map[string]string) error {
 				s.Input = i
 			case "min":
 				s.Minimum = i
+				setPresent(s, k)
 			}
 		case "alarm":
 			s.Alarm = v != "0"
+			setPresent(s, k)
 		case "beep":
 			s.Beep = v != "0"
+			setPresent(s, k)
+		case "label":
+			s.Label = v
+		case "fault":
+			// The driver reports that the fan is faulty
+			if v != "0" {
+				invalidate(s, "input", Validity{State: StateFaulted})
+			}
 		}
 	}
 
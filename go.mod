@@ -0,0 +1,3 @@
+module github.com/mdlayher/lmsensors
+
+go 1.21
@@ -0,0 +1,119 @@
+// Package graphite writes lmsensors Devices in the Graphite plaintext
+// protocol, and streams them to a Graphite server.
+package graphite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/fields"
+	"github.com/mdlayher/lmsensors/internal/stream"
+)
+
+// An Encoder writes Devices as metrics in the Graphite plaintext protocol.
+//
+// Each value of each Sensor is written as a metric whose path is
+// "host.chip.sensor.field", such as
+// "web01.it8728-isa-0a30.temp1.input".  Fields are the subfeatures of each
+// Sensor reported by lmsensors.Subfeatures, and the "duty" and "enable"
+// values of PWMSensors.  Alarms and beeps are written as 0 or 1, and values
+// which are not finite, such as NaN, are skipped, as Graphite cannot store
+// them.
+type Encoder struct {
+	// Host specifies the first segment of each metric path, typically the
+	// host name of the machine on which Devices were scanned.  If empty,
+	// paths begin with the chip name.
+	Host string
+
+	// Time specifies the timestamp of each metric.  If zero, the current
+	// time is used.
+	Time time.Time
+
+	w io.Writer
+}
+
+// NewEncoder creates an Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the metrics of devices to the Encoder's io.Writer.
+func (e *Encoder) Encode(devices []*lmsensors.Device) error {
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	var host string
+	if e.Host != "" {
+		host = sanitize(e.Host) + "."
+	}
+
+	bw := bufio.NewWriter(e.w)
+	for _, d := range devices {
+		chip := sanitize(d.ChipName().String())
+
+		for _, s := range d.Sensors {
+			for _, f := range fields.Of(s) {
+				if !f.Finite() {
+					continue
+				}
+
+				fmt.Fprintf(bw, "%s%s.%s.%s %s %s\n",
+					host, chip, sanitize(f.Sensor), sanitize(f.Name),
+					strconv.FormatFloat(f.Value, 'f', -1, 64),
+					ts,
+				)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// invalidChars matches characters which are not valid in a path segment.
+var invalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// sanitize converts s into a single path segment, replacing dots, spaces,
+// and other characters with special meaning to Graphite.
+func sanitize(s string) string {
+	return invalidChars.ReplaceAllLiteralString(s, "_")
+}
+
+// A Client streams Devices to a Graphite server over TCP.
+//
+// A Client connects when Devices are first sent, and reconnects after an
+// error.  Metrics which cannot be sent are buffered, up to MaxBuffer bytes,
+// and sent with the next Devices.
+type Client struct {
+	// Host specifies the first segment of each metric path, as it does for
+	// an Encoder.
+	Host string
+
+	*stream.Client
+}
+
+// NewClient creates a Client which sends metrics to the Graphite server at
+// addr, such as "graphite:2003".
+func NewClient(addr string) *Client {
+	return &Client{Client: stream.New(addr)}
+}
+
+// Send sends the metrics of devices, with timestamp t.  If the metrics
+// cannot be sent, Send returns an error, and they are buffered to be sent
+// with the next Devices.
+func (c *Client) Send(devices []*lmsensors.Device, t time.Time) error {
+	return c.Client.Send(func(w io.Writer) error {
+		e := NewEncoder(w)
+		e.Host = c.Host
+		e.Time = t
+
+		return e.Encode(devices)
+	})
+}
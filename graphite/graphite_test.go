@@ -0,0 +1,108 @@
+package graphite
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/lmsensorstest"
+)
+
+func TestEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		devices []*lmsensors.Device
+		want    string
+	}{
+		{
+			name:    "no host",
+			devices: lmsensorstest.Devices(),
+			want: `it8728-isa-0a30.fan1.input 1130 1500000000
+it8728-isa-0a30.fan1.min 300 1500000000
+it8728-isa-0a30.fan1.alarm 1 1500000000
+it8728-isa-0a30.pwm1.duty 128 1500000000
+it8728-isa-0a30.pwm1.enable 2 1500000000
+it8728-isa-0a30.temp1.input 43.5 1500000000
+it8728-isa-0a30.temp1.crit 100 1500000000
+it8728-isa-0a30.temp1.alarm 0 1500000000
+it8728-isa-0a30.temp1.crit_alarm 0 1500000000
+it8728-isa-0a30.temp1.type 4 1500000000
+power_meter-acpi-0.power1.average 12.5 1500000000
+power_meter-acpi-0.power1.average_interval 0.5 1500000000
+`,
+		},
+		{
+			name: "sanitized host",
+			host: "web01.example.com",
+			devices: []*lmsensors.Device{{
+				Name: "acpitz-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "web01_example_com.acpitz-virtual-0.temp1.input 27.8 1500000000\n",
+		},
+		{
+			name: "sanitized chip name",
+			devices: []*lmsensors.Device{{
+				Name: "my chip.1-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "my_chip_1-virtual-0.temp1.input 27.8 1500000000\n",
+		},
+		{
+			name: "not finite",
+			devices: []*lmsensors.Device{{
+				Name: "acpitz-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Input:      math.NaN(),
+						Attributes: map[string]bool{},
+					},
+					&lmsensors.TemperatureSensor{
+						Name:       "temp2",
+						Input:      math.Inf(1),
+						Attributes: map[string]bool{},
+					},
+					&lmsensors.TemperatureSensor{
+						Name:       "temp3",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "acpitz-virtual-0.temp3.input 27.8 1500000000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.Host = tt.host
+			e.Time = time.Unix(1500000000, 0)
+
+			if err := e.Encode(tt.devices); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.want, buf.String(); want != got {
+				t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+			}
+		})
+	}
+}
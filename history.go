@@ -0,0 +1,47 @@
+package lmsensors
+
+import (
+	"os"
+)
+
+// ResetHistory resets the lowest and highest values recorded by Sensor s,
+// such as the Lowest and Highest fields of a TemperatureSensor.  The
+// recorded values are reflected in the results of the next Scan.
+//
+// Resetting history typically requires root privileges; if permission is
+// denied, a *PermissionError is returned.
+func (d *Device) ResetHistory(s Sensor) error {
+	return d.writeAttribute(s.name()+"_reset_history", "1")
+}
+
+// ResetAllHistory resets the lowest and highest values recorded by all of
+// the Device's sensors.  If the Device does not support resetting the
+// history of all sensors at once, the history of each kind of sensor is
+// reset instead.
+//
+// Resetting history typically requires root privileges; if permission is
+// denied, a *PermissionError is returned.
+func (d *Device) ResetAllHistory() error {
+	err := d.writeAttribute("reset_history", "1")
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	// Fall back to the chip-wide attributes for each kind of sensor
+	var reset bool
+	for _, kind := range []string{"curr", "in", "power", "temp"} {
+		kerr := d.writeAttribute(kind+"_reset_history", "1")
+		switch {
+		case kerr == nil:
+			reset = true
+		case !os.IsNotExist(kerr):
+			return kerr
+		}
+	}
+
+	if !reset {
+		return err
+	}
+
+	return nil
+}
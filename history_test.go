@@ -0,0 +1,90 @@
+package lmsensors
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDeviceResetHistory(t *testing.T) {
+	const dir = "/sys/devices/platform/nct6775.656"
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{name: dir + "/temp1_reset_history"},
+			{
+				name:     dir + "/in0_reset_history",
+				writeErr: &os.PathError{Op: "open", Path: dir + "/in0_reset_history", Err: os.ErrPermission},
+			},
+		},
+	}
+
+	d := &Device{
+		Path: dir,
+		fs:   fs,
+	}
+
+	if err := d.ResetHistory(&TemperatureSensor{Name: "temp1"}); err != nil {
+		t.Fatalf("failed to reset history: %v", err)
+	}
+
+	if want, got := "1", fs.contents(dir+"/temp1_reset_history"); want != got {
+		t.Fatalf("unexpected temp1_reset_history: %q != %q", want, got)
+	}
+
+	err := d.ResetHistory(&VoltageSensor{Name: "in0"})
+	if _, ok := err.(*PermissionError); !ok {
+		t.Fatalf("expected permission error, but got: %v", err)
+	}
+}
+
+func TestDeviceResetAllHistory(t *testing.T) {
+	const dir = "/sys/devices/platform/nct6775.656"
+
+	tests := []struct {
+		name  string
+		files []string
+		ok    bool
+	}{
+		{
+			name:  "chip-wide",
+			files: []string{"reset_history"},
+			ok:    true,
+		},
+		{
+			name:  "per kind",
+			files: []string{"in_reset_history", "temp_reset_history"},
+			ok:    true,
+		},
+		{
+			name: "unsupported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &memoryFilesystem{}
+			for _, f := range tt.files {
+				fs.files = append(fs.files, memoryFile{name: dir + "/" + f})
+			}
+
+			d := &Device{
+				Path: dir,
+				fs:   fs,
+			}
+
+			err := d.ResetAllHistory()
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !tt.ok {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			for _, f := range tt.files {
+				if want, got := "1", fs.contents(dir+"/"+f); want != got {
+					t.Fatalf("unexpected %s: %q != %q", f, want, got)
+				}
+			}
+		})
+	}
+}
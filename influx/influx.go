@@ -0,0 +1,118 @@
+// Package influx writes lmsensors Devices in the InfluxDB line protocol.
+package influx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/fields"
+)
+
+// An Encoder writes Devices as points in the InfluxDB line protocol.
+//
+// Each Sensor is written as one point, whose measurement is the kind of
+// Sensor, such as "temperature".  Points are tagged with the chip name of
+// their Device, its bus, and the name and label of their Sensor.  Fields
+// are the subfeatures of each Sensor reported by lmsensors.Subfeatures,
+// such as "input" and "crit", with alarms and beeps written as booleans.
+// PWMSensors are written with integer "duty" and "enable" fields.
+type Encoder struct {
+	// Time, if non-zero, is the timestamp written with every point.  If
+	// Time is zero, points are written without a timestamp, and are
+	// assigned one by the server when received.
+	Time time.Time
+
+	// Precision specifies the precision of timestamps, which must match
+	// the precision configured for writes on the server: one of
+	// time.Nanosecond, time.Microsecond, time.Millisecond, or time.Second.
+	// If zero, time.Nanosecond is used.
+	Precision time.Duration
+
+	w io.Writer
+}
+
+// NewEncoder creates an Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the points of devices to the Encoder's io.Writer.
+func (e *Encoder) Encode(devices []*lmsensors.Device) error {
+	precision := e.Precision
+	switch precision {
+	case 0:
+		precision = time.Nanosecond
+	case time.Nanosecond, time.Microsecond, time.Millisecond, time.Second:
+	default:
+		return fmt.Errorf("influx: invalid precision %v", precision)
+	}
+
+	var ts string
+	if !e.Time.IsZero() {
+		ts = " " + strconv.FormatInt(e.Time.UnixNano()/int64(precision), 10)
+	}
+
+	bw := bufio.NewWriter(e.w)
+	for _, d := range devices {
+		c := d.ChipName()
+
+		for _, s := range d.Sensors {
+			fs := fields.Of(s)
+			if len(fs) == 0 {
+				continue
+			}
+
+			tags := []string{
+				"bus=" + tagEscaper.Replace(string(c.Bus)),
+				"chip=" + tagEscaper.Replace(c.String()),
+			}
+			if l := fs[0].Label; l != "" {
+				tags = append(tags, "label="+tagEscaper.Replace(l))
+			}
+			tags = append(tags, "sensor="+tagEscaper.Replace(fs[0].Sensor))
+
+			fmt.Fprintf(bw, "%s,%s %s%s\n",
+				measurementEscaper.Replace(fs[0].Kind),
+				strings.Join(tags, ","),
+				strings.Join(fieldSet(fs), ","),
+				ts,
+			)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// fieldSet formats fs as line protocol fields, sorted by key.
+func fieldSet(fs []fields.Field) []string {
+	out := make([]string, 0, len(fs))
+	for _, f := range fs {
+		key := tagEscaper.Replace(f.Name)
+
+		switch {
+		case f.Integer():
+			out = append(out, fmt.Sprintf("%s=%di", key, int64(f.Value)))
+		case f.Boolean():
+			out = append(out, fmt.Sprintf("%s=%t", key, f.Value != 0))
+		default:
+			out = append(out, key+"="+strconv.FormatFloat(f.Value, 'f', -1, 64))
+		}
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+var (
+	// measurementEscaper escapes measurement names
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+
+	// tagEscaper escapes tag keys, tag values, and field keys
+	tagEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+)
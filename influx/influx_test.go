@@ -0,0 +1,126 @@
+package influx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+func TestEncoder(t *testing.T) {
+	devices := []*lmsensors.Device{
+		{
+			Name:       "it8728-00",
+			DevicePath: "/sys/devices/platform/it87.2608",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.FanSensor{
+					Name:    "fan1",
+					Input:   1130,
+					Minimum: 300,
+					Alarm:   true,
+				},
+				&lmsensors.PWMSensor{
+					Name:   "pwm1",
+					Enable: lmsensors.PWMEnableAutomatic,
+					Duty:   128,
+				},
+				&lmsensors.TemperatureSensor{
+					Name:     "temp1",
+					Label:    "CPU, socket=0",
+					Type:     lmsensors.TemperatureSensorTypeThermistor,
+					Input:    43.5,
+					Critical: 100.0,
+				},
+				&lmsensors.VoltageSensor{
+					Name: "in0",
+					Validity: map[string]lmsensors.Validity{
+						"input": {State: lmsensors.StateUnavailable},
+					},
+				},
+			},
+		},
+		{
+			Name: "acpitz-00",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.PowerSensor{
+					Name:            "power1",
+					Average:         12.5,
+					AverageInterval: 500 * time.Millisecond,
+				},
+			},
+		},
+	}
+
+	ts := time.Unix(1500000000, 123456789)
+
+	tests := []struct {
+		name      string
+		time      time.Time
+		precision time.Duration
+		want      string
+		ok        bool
+	}{
+		{
+			name: "no timestamp",
+			want: `fan,bus=isa,chip=it8728-isa-0a30,sensor=fan1 alarm=true,input=1130,min=300
+pwm,bus=isa,chip=it8728-isa-0a30,sensor=pwm1 duty=128i,enable=2i
+temperature,bus=isa,chip=it8728-isa-0a30,label=CPU\,\ socket\=0,sensor=temp1 alarm=false,crit=100,crit_alarm=false,input=43.5,type=4i
+voltage,bus=isa,chip=it8728-isa-0a30,sensor=in0 alarm=false
+power,bus=virtual,chip=acpitz-virtual-0,sensor=power1 average=12.5,average_interval=0.5
+`,
+			ok: true,
+		},
+		{
+			name: "nanoseconds",
+			time: ts,
+			want: `fan,bus=isa,chip=it8728-isa-0a30,sensor=fan1 alarm=true,input=1130,min=300 1500000000123456789
+pwm,bus=isa,chip=it8728-isa-0a30,sensor=pwm1 duty=128i,enable=2i 1500000000123456789
+temperature,bus=isa,chip=it8728-isa-0a30,label=CPU\,\ socket\=0,sensor=temp1 alarm=false,crit=100,crit_alarm=false,input=43.5,type=4i 1500000000123456789
+voltage,bus=isa,chip=it8728-isa-0a30,sensor=in0 alarm=false 1500000000123456789
+power,bus=virtual,chip=acpitz-virtual-0,sensor=power1 average=12.5,average_interval=0.5 1500000000123456789
+`,
+			ok: true,
+		},
+		{
+			name:      "seconds",
+			time:      ts,
+			precision: time.Second,
+			want: `fan,bus=isa,chip=it8728-isa-0a30,sensor=fan1 alarm=true,input=1130,min=300 1500000000
+pwm,bus=isa,chip=it8728-isa-0a30,sensor=pwm1 duty=128i,enable=2i 1500000000
+temperature,bus=isa,chip=it8728-isa-0a30,label=CPU\,\ socket\=0,sensor=temp1 alarm=false,crit=100,crit_alarm=false,input=43.5,type=4i 1500000000
+voltage,bus=isa,chip=it8728-isa-0a30,sensor=in0 alarm=false 1500000000
+power,bus=virtual,chip=acpitz-virtual-0,sensor=power1 average=12.5,average_interval=0.5 1500000000
+`,
+			ok: true,
+		},
+		{
+			name:      "invalid precision",
+			precision: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.Time = tt.time
+			e.Precision = tt.precision
+
+			err := e.Encode(devices)
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !tt.ok {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if !tt.ok {
+				return
+			}
+
+			if want, got := tt.want, buf.String(); want != got {
+				t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+			}
+		})
+	}
+}
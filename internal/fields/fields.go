@@ -0,0 +1,85 @@
+// Package fields flattens lmsensors Sensors into named values, for output
+// formats which have no notion of a Sensor.
+package fields
+
+import (
+	"math"
+	"strings"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// A Field is a single value of a Sensor.
+type Field struct {
+	// The kind of Sensor, such as "temperature", and its name and label.
+	Kind   string
+	Sensor string
+	Label  string
+
+	// The name of the value, such as "input" or "crit_alarm", and the
+	// value itself.
+	Name  string
+	Value float64
+}
+
+// Integer reports whether the value of the Field is an integer, such as
+// the duty cycle of a PWMSensor or the type of a TemperatureSensor.
+func (f Field) Integer() bool {
+	return f.Kind == "pwm" || f.Name == "type"
+}
+
+// Boolean reports whether the value of the Field is a boolean, such as an
+// alarm.
+func (f Field) Boolean() bool {
+	return f.Name == "beep" || strings.HasSuffix(f.Name, "alarm")
+}
+
+// Finite reports whether the value of the Field is finite, rather than NaN
+// or an infinity.
+func (f Field) Finite() bool {
+	return !math.IsNaN(f.Value) && !math.IsInf(f.Value, 0)
+}
+
+// Of returns the Fields of Sensor s.  The Fields of most Sensors are their
+// subfeatures, as reported by lmsensors.Subfeatures.  PWMSensors have
+// "duty" and "enable" Fields.
+func Of(s lmsensors.Sensor) []Field {
+	if pwm, ok := s.(*lmsensors.PWMSensor); ok {
+		var fs []Field
+		if pwm.Validity[""].OK() {
+			fs = append(fs, Field{Kind: "pwm", Sensor: pwm.Name, Name: "duty", Value: float64(pwm.Duty)})
+		}
+		if pwm.Validity["enable"].OK() {
+			fs = append(fs, Field{Kind: "pwm", Sensor: pwm.Name, Name: "enable", Value: float64(pwm.Enable)})
+		}
+
+		return fs
+	}
+
+	label := lmsensors.SensorLabel(s)
+
+	var fs []Field
+	for _, sf := range lmsensors.Subfeatures(s) {
+		ss := strings.SplitN(sf.Name, "_", 2)
+
+		fs = append(fs, Field{
+			Kind:   kinds[strings.TrimRight(ss[0], "0123456789")],
+			Sensor: ss[0],
+			Label:  label,
+			Name:   ss[1],
+			Value:  sf.Value,
+		})
+	}
+
+	return fs
+}
+
+// kinds maps Sensor name prefixes to kinds.
+var kinds = map[string]string{
+	"curr":      "current",
+	"fan":       "fan",
+	"in":        "voltage",
+	"intrusion": "intrusion",
+	"power":     "power",
+	"temp":      "temperature",
+}
@@ -0,0 +1,94 @@
+package fields
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		sensor lmsensors.Sensor
+		fields []Field
+	}{
+		{
+			name: "fan",
+			sensor: &lmsensors.FanSensor{
+				Name:    "fan1",
+				Input:   1130,
+				Minimum: 300,
+				Alarm:   true,
+			},
+			fields: []Field{
+				{Kind: "fan", Sensor: "fan1", Name: "input", Value: 1130},
+				{Kind: "fan", Sensor: "fan1", Name: "min", Value: 300},
+				{Kind: "fan", Sensor: "fan1", Name: "alarm", Value: 1},
+			},
+		},
+		{
+			name: "PWM",
+			sensor: &lmsensors.PWMSensor{
+				Name:   "pwm1",
+				Enable: lmsensors.PWMEnableAutomatic,
+				Duty:   128,
+			},
+			fields: []Field{
+				{Kind: "pwm", Sensor: "pwm1", Name: "duty", Value: 128},
+				{Kind: "pwm", Sensor: "pwm1", Name: "enable", Value: 2},
+			},
+		},
+		{
+			name: "PWM unreadable duty",
+			sensor: &lmsensors.PWMSensor{
+				Name:   "pwm1",
+				Enable: lmsensors.PWMEnableManual,
+				Validity: map[string]lmsensors.Validity{
+					"": {State: lmsensors.StateUnavailable},
+				},
+			},
+			fields: []Field{
+				{Kind: "pwm", Sensor: "pwm1", Name: "enable", Value: 1},
+			},
+		},
+		{
+			name: "temperature",
+			sensor: &lmsensors.TemperatureSensor{
+				Name:     "temp1",
+				Label:    "CPU, socket=0",
+				Type:     lmsensors.TemperatureSensorTypeThermistor,
+				Input:    43.5,
+				Critical: 100.0,
+			},
+			fields: []Field{
+				{Kind: "temperature", Sensor: "temp1", Label: "CPU, socket=0", Name: "input", Value: 43.5},
+				{Kind: "temperature", Sensor: "temp1", Label: "CPU, socket=0", Name: "crit", Value: 100},
+				{Kind: "temperature", Sensor: "temp1", Label: "CPU, socket=0", Name: "alarm", Value: 0},
+				{Kind: "temperature", Sensor: "temp1", Label: "CPU, socket=0", Name: "crit_alarm", Value: 0},
+				{Kind: "temperature", Sensor: "temp1", Label: "CPU, socket=0", Name: "type", Value: 4},
+			},
+		},
+		{
+			name: "power",
+			sensor: &lmsensors.PowerSensor{
+				Name:            "power1",
+				Average:         12.5,
+				AverageInterval: 500 * time.Millisecond,
+			},
+			fields: []Field{
+				{Kind: "power", Sensor: "power1", Name: "average", Value: 12.5},
+				{Kind: "power", Sensor: "power1", Name: "average_interval", Value: 0.5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.fields, Of(tt.sensor); !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Fields:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
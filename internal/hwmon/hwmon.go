@@ -0,0 +1,231 @@
+// Package hwmon converts lmsensors Devices into metric samples named as the
+// hwmon collector of the Prometheus node_exporter names them.
+package hwmon
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// Namespace is the prefix of all metric names.
+const Namespace = "node_hwmon"
+
+// A Sample is a single sample of a gauge metric.
+type Sample struct {
+	Name   string
+	Help   string
+	Labels []Label
+	Value  float64
+}
+
+// A Label is a metric label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// ChipLabels returns the "chip" label of each of devices.
+func ChipLabels(devices []*lmsensors.Device) []string {
+	chips := make([]string, 0, len(devices))
+	seen := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		chip := chipLabel(d)
+
+		// Devices without a device path may share a chip name, so fall
+		// back to the hwmon directory name to keep labels unique
+		if seen[chip] {
+			chip = cleanName(d.Hwmon)
+		}
+		seen[chip] = true
+
+		chips = append(chips, chip)
+	}
+
+	return chips
+}
+
+// ScrapeSamples returns samples which describe the result of scanning a
+// Device, labeled with its chip label.
+func ScrapeSamples(r *lmsensors.ScanResult, chip string) []Sample {
+	var failed float64
+	if r.Err != nil {
+		failed = 1
+	}
+
+	labels := []Label{{Name: "chip", Value: chip}}
+
+	return []Sample{
+		{
+			Name:   Namespace + "_scrape_duration_seconds",
+			Help:   "Time taken to scan a chip",
+			Labels: labels,
+			Value:  r.Duration.Seconds(),
+		},
+		{
+			Name:   Namespace + "_scrape_error",
+			Help:   "Whether or not an error occurred while scanning a chip",
+			Labels: labels,
+			Value:  failed,
+		},
+	}
+}
+
+// DeviceSamples returns the samples of Device d and its Sensors, labeled
+// with its chip label.
+//
+// Readings and limits use the names used by node_exporter, such as
+// node_hwmon_temp_celsius and node_hwmon_fan_min_rpm, with "chip" and
+// "sensor" labels.  The label of each Sensor is sampled as
+// node_hwmon_sensor_label, and the chip name of d as node_hwmon_chip_names.
+func DeviceSamples(d *lmsensors.Device, chip string) []Sample {
+	samples := []Sample{{
+		Name: Namespace + "_chip_names",
+		Help: "Annotation metric for human-readable chip names",
+		Labels: []Label{
+			{Name: "chip", Value: chip},
+			{Name: "chip_name", Value: d.ChipName().Prefix},
+		},
+		Value: 1,
+	}}
+
+	for _, s := range d.Sensors {
+		samples = append(samples, sensorSamples(s, chip)...)
+	}
+
+	return samples
+}
+
+// sensorSamples returns the samples of a single Sensor.
+func sensorSamples(s lmsensors.Sensor, chip string) []Sample {
+	// PWMSensors have no subfeatures in libsensors, so they are sampled
+	// from their attributes, as node_exporter does
+	if pwm, ok := s.(*lmsensors.PWMSensor); ok {
+		var samples []Sample
+		if pwm.Validity[""].OK() {
+			samples = append(samples, gauge("pwm", "", float64(pwm.Duty), chip, pwm.Name))
+		}
+		if pwm.Validity["enable"].OK() {
+			samples = append(samples, gauge("pwm", "enable", float64(pwm.Enable), chip, pwm.Name))
+		}
+
+		return samples
+	}
+
+	var samples []Sample
+	for i, sf := range lmsensors.Subfeatures(s) {
+		fs := strings.SplitN(sf.Name, "_", 2)
+		sensor, attr := fs[0], fs[1]
+
+		if i == 0 {
+			if l := lmsensors.SensorLabel(s); l != "" {
+				samples = append(samples, Sample{
+					Name: Namespace + "_sensor_label",
+					Help: "Label for given chip and sensor",
+					Labels: []Label{
+						{Name: "chip", Value: chip},
+						{Name: "sensor", Value: sensor},
+						{Name: "label", Value: l},
+					},
+					Value: 1,
+				})
+			}
+		}
+
+		samples = append(samples, gauge(strings.TrimRight(sensor, "0123456789"), attr, sf.Value, chip, sensor))
+	}
+
+	return samples
+}
+
+// gauge creates a sample for attribute attr of a Sensor of kind, such as
+// "temp", with the name and help text node_exporter uses.  The "input"
+// attribute is the Sensor's reading, and is sampled without an attribute
+// suffix.
+//
+// As in node_exporter, only the "alarm" and "beep" attributes have no unit
+// suffix, so that other alarms are named like limits, such as
+// node_hwmon_temp_crit_alarm_celsius.
+func gauge(kind, attr string, v float64, chip, sensor string) Sample {
+	name := Namespace + "_" + kind
+	if attr != "" && attr != "input" {
+		name += "_" + attr
+	}
+
+	element := attr
+	if element == "" && kind != "pwm" {
+		element = "input"
+	}
+
+	var unit, help string
+	switch {
+	case attr == "alarm", attr == "beep":
+		help = fmt.Sprintf("Hardware sensor %s status (%s)", attr, kind)
+	case kind == "power" && attr == "average_interval":
+		unit, help = "seconds", "Hardware monitor power usage update interval (%s)"
+	case kind == "power":
+		unit, help = "watt", "Hardware monitor for power usage in watts (%s)"
+	case kind == "curr":
+		unit, help = "amps", "Hardware monitor for current (%s)"
+	case kind == "temp" && attr != "type":
+		unit, help = "celsius", "Hardware monitor for temperature (%s)"
+	case kind == "in":
+		unit, help = "volts", "Hardware monitor for voltage (%s)"
+	case kind == "fan" && (element == "input" || attr == "min" || attr == "max" || attr == "target"):
+		unit, help = "rpm", "Hardware monitor for fan revolutions per minute (%s)"
+	default:
+		help = "Hardware monitor " + kind + " element " + element
+	}
+
+	if unit != "" {
+		name += "_" + unit
+		help = fmt.Sprintf(help, element)
+	}
+
+	return Sample{
+		Name: name,
+		Help: help,
+		Labels: []Label{
+			{Name: "chip", Value: chip},
+			{Name: "sensor", Value: sensor},
+		},
+		Value: v,
+	}
+}
+
+// chipLabel returns the "chip" label of Device d, which node_exporter
+// derives from the bus and name of the device backing the hwmon directory,
+// such as "platform_coretemp_0".
+func chipLabel(d *lmsensors.Device) string {
+	if d.DevicePath != "" {
+		dir, dev := path.Split(d.DevicePath)
+		bus := cleanName(path.Base(dir))
+		dev = cleanName(dev)
+
+		switch {
+		case bus != "" && dev != "":
+			return bus + "_" + dev
+		case dev != "":
+			return dev
+		}
+	}
+
+	if n := cleanName(d.ChipName().Prefix); n != "" {
+		return n
+	}
+
+	return cleanName(d.Hwmon)
+}
+
+// invalidChars matches characters which node_exporter removes from chip
+// labels.
+var invalidChars = regexp.MustCompile("[^a-z0-9:_]")
+
+// cleanName converts s into a chip label as node_exporter does.
+func cleanName(s string) string {
+	s = invalidChars.ReplaceAllLiteralString(strings.ToLower(s), "_")
+	return strings.Trim(s, "_")
+}
@@ -0,0 +1,64 @@
+// Package lmsensorstest provides Devices and a Scanner for testing the
+// packages which consume lmsensors Devices.
+package lmsensorstest
+
+import (
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+)
+
+// Devices returns Devices with a Sensor of each common kind, as an it8728
+// Super I/O chip and an ACPI power meter report them.
+func Devices() []*lmsensors.Device {
+	return []*lmsensors.Device{
+		{
+			Name:       "it8728-00",
+			Hwmon:      "hwmon1",
+			DevicePath: "/sys/devices/platform/it87.2608",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.FanSensor{
+					Name:    "fan1",
+					Input:   1130,
+					Minimum: 300,
+					Alarm:   true,
+				},
+				&lmsensors.PWMSensor{
+					Name:   "pwm1",
+					Enable: lmsensors.PWMEnableAutomatic,
+					Duty:   128,
+				},
+				&lmsensors.TemperatureSensor{
+					Name:     "temp1",
+					Label:    "CPU, socket=0",
+					Type:     lmsensors.TemperatureSensorTypeThermistor,
+					Input:    43.5,
+					Critical: 100.0,
+				},
+			},
+		},
+		{
+			Name:       "power_meter-00",
+			Hwmon:      "hwmon2",
+			DevicePath: "/sys/devices/LNXSYSTM:00/device:00/ACPI000D:00",
+			Sensors: []lmsensors.Sensor{
+				&lmsensors.PowerSensor{
+					Name:            "power1",
+					Average:         12.5,
+					AverageInterval: 500 * time.Millisecond,
+				},
+			},
+		},
+	}
+}
+
+// A Scanner scans for Devices by returning fixed results.
+type Scanner struct {
+	Results []*lmsensors.ScanResult
+	Err     error
+}
+
+// ScanResults returns the Scanner's results and error.
+func (s *Scanner) ScanResults() ([]*lmsensors.ScanResult, error) {
+	return s.Results, s.Err
+}
@@ -0,0 +1,163 @@
+// Package stream implements a client which streams lines of text to a TCP
+// server, reconnecting and buffering lines while the server is unreachable.
+package stream
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Defaults for Client fields.
+const (
+	DefaultMaxBuffer = 1 << 20
+	DefaultTimeout   = 10 * time.Second
+)
+
+// A Client streams lines of text to a TCP server.
+//
+// A Client connects when lines are first written, and reconnects after an
+// error.  Lines which cannot be sent are buffered and sent, in order, with
+// the next lines written.  A line which was partially sent when an error
+// occurred is sent again in full, so servers may receive a line more than
+// once.
+type Client struct {
+	// MaxBuffer specifies the maximum number of bytes of lines buffered
+	// while the server is unreachable.  When the buffer is full, the
+	// oldest lines are discarded.
+	MaxBuffer int
+
+	// Timeout specifies the timeout for connecting to the server and for
+	// each write.
+	Timeout time.Duration
+
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  [][]byte
+	size int
+}
+
+// New creates a Client which sends lines to the TCP server at addr.
+func New(addr string) *Client {
+	return &Client{
+		MaxBuffer: DefaultMaxBuffer,
+		Timeout:   DefaultTimeout,
+		addr:      addr,
+	}
+}
+
+// Write buffers the newline-terminated lines in b, and sends all buffered
+// lines to the server.  If the lines cannot be sent, Write returns an
+// error, but the lines remain buffered, and len(b) is returned.
+func (c *Client) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(b)
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i == -1 {
+			i = len(b) - 1
+		}
+
+		line := make([]byte, i+1)
+		copy(line, b)
+		b = b[i+1:]
+
+		c.buf = append(c.buf, line)
+		c.size += len(line)
+	}
+
+	for c.size > c.MaxBuffer && len(c.buf) > 0 {
+		c.size -= len(c.buf[0])
+		c.buf = c.buf[1:]
+	}
+
+	return n, c.flush()
+}
+
+// Send writes the lines written by encode, as Write does.  If encode
+// returns an error, no lines are written.
+func (c *Client) Send(encode func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return err
+	}
+
+	_, err := c.Write(buf.Bytes())
+	return err
+}
+
+// Buffered returns the number of bytes of lines which have not been sent.
+func (c *Client) Buffered() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// Close closes the Client's connection.  Buffered lines are discarded.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buf, c.size = nil, 0
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// flush sends all buffered lines, connecting if necessary.
+func (c *Client) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, c.Timeout)
+		if err != nil {
+			return err
+		}
+
+		c.conn = conn
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return c.reset(err)
+	}
+
+	// WriteTo consumes the byte slices it writes, so it is given a copy of
+	// the buffer
+	bufs := append(net.Buffers(nil), c.buf...)
+	n, err := bufs.WriteTo(c.conn)
+
+	// Discard the lines which were sent in full
+	for len(c.buf) > 0 && n >= int64(len(c.buf[0])) {
+		n -= int64(len(c.buf[0]))
+		c.size -= len(c.buf[0])
+		c.buf = c.buf[1:]
+	}
+
+	if err != nil {
+		return c.reset(err)
+	}
+
+	return nil
+}
+
+// reset closes the Client's connection after an error, so that it is
+// reconnected by the next write, and returns err.
+func (c *Client) reset(err error) error {
+	c.conn.Close()
+	c.conn = nil
+	return err
+}
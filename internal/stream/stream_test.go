@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientWrite(t *testing.T) {
+	l, lines := listen(t, "127.0.0.1:0")
+	defer l.Close()
+
+	c := New(l.Addr().String())
+	defer c.Close()
+
+	write(t, c, "a 1\nb 2\n")
+	write(t, c, "c 3\n")
+
+	expectLines(t, lines, "a 1", "b 2", "c 3")
+}
+
+func TestClientSend(t *testing.T) {
+	l, lines := listen(t, "127.0.0.1:0")
+	defer l.Close()
+
+	c := New(l.Addr().String())
+	defer c.Close()
+
+	err := c.Send(func(w io.Writer) error {
+		_, err := io.WriteString(w, "a 1\nb 2\n")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	expectLines(t, lines, "a 1", "b 2")
+
+	// Lines are not written if they cannot be encoded
+	err = c.Send(func(w io.Writer) error {
+		io.WriteString(w, "c 3\n")
+		return errors.New("broken")
+	})
+	if err == nil || err.Error() != "broken" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	write(t, c, "d 4\n")
+	expectLines(t, lines, "d 4")
+}
+
+func TestClientReconnect(t *testing.T) {
+	// Find a free address, and leave nothing listening on it
+	l, _ := listen(t, "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+
+	c := New(addr)
+	c.Timeout = time.Second
+	defer c.Close()
+
+	if _, err := c.Write([]byte("a 1\nb 2\n")); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	if want, got := len("a 1\nb 2\n"), c.Buffered(); want != got {
+		t.Fatalf("unexpected number of buffered bytes: %d != %d", want, got)
+	}
+
+	// Lines buffered while the server was unreachable are sent first
+	l, lines := listen(t, addr)
+	defer l.Close()
+
+	write(t, c, "c 3\n")
+	expectLines(t, lines, "a 1", "b 2", "c 3")
+
+	if want, got := 0, c.Buffered(); want != got {
+		t.Fatalf("unexpected number of buffered bytes: %d != %d", want, got)
+	}
+}
+
+func TestClientMaxBuffer(t *testing.T) {
+	l, _ := listen(t, "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+
+	c := New(addr)
+	c.MaxBuffer = 8
+	defer c.Close()
+
+	// The oldest lines are discarded when the buffer is full
+	c.Write([]byte("a 1\nb 2\nc 3\n"))
+
+	if want, got := len("b 2\nc 3\n"), c.Buffered(); want != got {
+		t.Fatalf("unexpected number of buffered bytes: %d != %d", want, got)
+	}
+}
+
+// listen starts a TCP server at addr which sends each line it receives on
+// the returned channel.
+func listen(t *testing.T, addr string) (net.Listener, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	lines := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					lines <- s.Text()
+				}
+			}()
+		}
+	}()
+
+	return l, lines
+}
+
+// write writes s to c, failing the test on error.
+func write(t *testing.T, c *Client, s string) {
+	t.Helper()
+
+	if _, err := c.Write([]byte(s)); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+}
+
+// expectLines verifies that the lines received by a server are want.
+func expectLines(t *testing.T, lines <-chan string, want ...string) {
+	t.Helper()
+
+	for _, w := range want {
+		select {
+		case got := <-lines:
+			if w != got {
+				t.Fatalf("unexpected line:\n- want: %q\n-  got: %q", w, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for line %q", w)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package lmsensors
 
+import (
+	"fmt"
+	"time"
+)
+
 var _ Sensor = &IntrusionSensor{}
 
 // An IntrusionSensor is a Sensor that detects when the machine's chassis
@@ -11,18 +16,137 @@ type IntrusionSensor struct {
 	// Whether or not the machine's chassis has been opened, and the alarm
 	// has been triggered.
 	Alarm bool
+
+	// Whether or not a beep is generated when the alarm is triggered.
+	Beep bool
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
 }
 
-func (s *IntrusionSensor) name() string        { return s.Name }
-func (s *IntrusionSensor) setName(name string) { s.Name = name }
+func (s *IntrusionSensor) name() string                      { return s.Name }
+func (s *IntrusionSensor) setName(name string)               { s.Name = name }
+func (s *IntrusionSensor) validity() map[string]Validity     { return s.Validity }
+func (s *IntrusionSensor) setValidity(v map[string]Validity) { s.Validity = v }
 
 func (s *IntrusionSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
 		switch k {
 		case "alarm":
 			s.Alarm = v != "0"
+		case "beep":
+			s.Beep = v != "0"
 		}
 	}
 
 	return nil
 }
+
+// An IntrusionRecord records the state of the alarm of an IntrusionSensor,
+// so that a new intrusion can be told apart from one which has already been
+// seen.  Records are created by Device.ClearIntrusion and
+// Device.CheckIntrusion, and are meant to be persisted by the caller between
+// scans, for example as JSON.
+type IntrusionRecord struct {
+	// The chip and sensor names of the IntrusionSensor, such as
+	// "it8728-isa-0a30" and "intrusion0".
+	Chip   string
+	Sensor string
+
+	// The time at which the alarm was last cleared, or zero if it has
+	// never been cleared through this package.
+	Cleared time.Time
+
+	// The time at which the alarm was first seen raised since it was last
+	// cleared, or zero if the alarm is not raised.
+	Raised time.Time
+}
+
+// An IntrusionStatus describes the alarm of an IntrusionSensor, relative to
+// a previous IntrusionRecord.
+type IntrusionStatus int
+
+// All possible IntrusionStatus constants.
+const (
+	// The alarm is not raised.
+	IntrusionNone IntrusionStatus = 0
+
+	// The alarm has been raised since the previous record was made.
+	IntrusionNew IntrusionStatus = 1
+
+	// The alarm was already raised when the previous record was made.
+	IntrusionStale IntrusionStatus = 2
+)
+
+// String returns the string representation of an IntrusionStatus.
+func (s IntrusionStatus) String() string {
+	switch s {
+	case IntrusionNone:
+		return "none"
+	case IntrusionNew:
+		return "new"
+	case IntrusionStale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// ClearIntrusion clears the alarm of IntrusionSensor s, and returns a record
+// of the time at which it was cleared.  An alarm which is raised after this
+// time indicates a new intrusion.
+//
+// Some hardware keeps the alarm raised while the chassis remains open; in
+// that case the alarm cannot be cleared and an error is returned.  Clearing
+// alarms typically requires root privileges; if permission is denied, a
+// *PermissionError is returned.
+func (d *Device) ClearIntrusion(s *IntrusionSensor) (*IntrusionRecord, error) {
+	v, err := d.setInt(s.Name+"_alarm", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if v != 0 {
+		s.Alarm = true
+		return nil, fmt.Errorf("lmsensors: intrusion alarm %q is still raised", s.Name)
+	}
+
+	s.Alarm = false
+	return &IntrusionRecord{
+		Chip:    d.ChipName().String(),
+		Sensor:  s.Name,
+		Cleared: d.now(),
+	}, nil
+}
+
+// CheckIntrusion compares the alarm of IntrusionSensor s with prev, the
+// record returned by a previous call to ClearIntrusion or CheckIntrusion for
+// the same sensor, and returns its status and a new record to replace prev.
+//
+// If prev is nil, or describes a different sensor, a raised alarm is
+// reported as a new intrusion.
+func (d *Device) CheckIntrusion(s *IntrusionSensor, prev *IntrusionRecord) (IntrusionStatus, *IntrusionRecord) {
+	rec := &IntrusionRecord{
+		Chip:   d.ChipName().String(),
+		Sensor: s.Name,
+	}
+
+	if prev != nil && prev.Chip == rec.Chip && prev.Sensor == rec.Sensor {
+		rec.Cleared = prev.Cleared
+		rec.Raised = prev.Raised
+	}
+
+	if !s.Alarm {
+		rec.Raised = time.Time{}
+		return IntrusionNone, rec
+	}
+
+	if !rec.Raised.IsZero() {
+		return IntrusionStale, rec
+	}
+
+	rec.Raised = d.now()
+	return IntrusionNew, rec
+}
@@ -0,0 +1,179 @@
+package lmsensors
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDeviceClearIntrusion(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608"
+
+	now := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		file memoryFile
+		ok   bool
+	}{
+		{
+			name: "cleared",
+			file: memoryFile{
+				name:     dir + "/intrusion0_alarm",
+				contents: "1",
+			},
+			ok: true,
+		},
+		{
+			name: "chassis still open",
+			file: memoryFile{
+				name:     dir + "/intrusion0_alarm",
+				contents: "1",
+				onWrite:  func(string) string { return "1" },
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Device{
+				Path:  dir,
+				fs:    &memoryFilesystem{files: []memoryFile{tt.file}},
+				clock: &fakeClock{now: now},
+			}
+
+			s := &IntrusionSensor{
+				Name:  "intrusion0",
+				Alarm: true,
+			}
+
+			rec, err := d.ClearIntrusion(s)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				if !s.Alarm || rec != nil {
+					t.Fatalf("unexpected sensor and record after failed clear: %#v, %#v", s, rec)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if s.Alarm {
+				t.Fatal("expected alarm to be cleared")
+			}
+
+			if want, got := "intrusion0", rec.Sensor; want != got {
+				t.Fatalf("unexpected record sensor: %q != %q", want, got)
+			}
+			if !rec.Cleared.Equal(now) {
+				t.Fatalf("unexpected clear time: %v", rec.Cleared)
+			}
+		})
+	}
+}
+
+func TestDeviceCheckIntrusion(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608/hwmon/hwmon1"
+
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.2608/hwmon/hwmon1",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{name: dir + "/name", contents: "it8728"},
+			{name: dir + "/intrusion0_alarm", contents: "1"},
+		},
+	}
+
+	clock := &fakeClock{
+		now: time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	s := &Scanner{
+		fs:    fs,
+		clock: clock,
+	}
+
+	// scan scans the intrusion sensor, advancing the clock between scans
+	scan := func() (*Device, *IntrusionSensor) {
+		clock.now = clock.now.Add(time.Hour)
+
+		devices, err := s.Scan()
+		if err != nil {
+			t.Fatalf("failed to scan: %v", err)
+		}
+
+		d := devices[0]
+		return d, d.Sensors[0].(*IntrusionSensor)
+	}
+
+	check := func(prev *IntrusionRecord, want IntrusionStatus) *IntrusionRecord {
+		t.Helper()
+
+		d, is := scan()
+		status, rec := d.CheckIntrusion(is, prev)
+		if status != want {
+			t.Fatalf("unexpected intrusion status: %v != %v", want, status)
+		}
+
+		return rec
+	}
+
+	// An alarm raised before any record was made is new, but the same
+	// alarm seen in a later scan is not
+	rec := check(nil, IntrusionNew)
+	raised := rec.Raised
+	if raised.IsZero() {
+		t.Fatal("expected raised time to be recorded")
+	}
+
+	rec = check(rec, IntrusionStale)
+	if !rec.Raised.Equal(raised) {
+		t.Fatalf("unexpected raised time: %v != %v", raised, rec.Raised)
+	}
+
+	d, is := scan()
+	rec, err := d.ClearIntrusion(is)
+	if err != nil {
+		t.Fatalf("failed to clear intrusion: %v", err)
+	}
+	cleared := rec.Cleared
+
+	rec = check(rec, IntrusionNone)
+
+	// The chassis is opened again after the alarm was cleared
+	fs.setContents(dir+"/intrusion0_alarm", "1")
+
+	rec = check(rec, IntrusionNew)
+	if !rec.Cleared.Equal(cleared) || !rec.Raised.After(cleared) {
+		t.Fatalf("unexpected record after new intrusion: %#v", rec)
+	}
+
+	// Records for other sensors are ignored
+	other := *rec
+	other.Sensor = "intrusion1"
+	check(&other, IntrusionNew)
+}
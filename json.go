@@ -0,0 +1,206 @@
+package lmsensors
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A JSONEncoder writes Devices in the JSON format of the lm-sensors
+// "sensors" command, as produced by "sensors -j".
+type JSONEncoder struct {
+	w io.Writer
+}
+
+// NewJSONEncoder creates a JSONEncoder which writes to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w}
+}
+
+// Encode writes devices to the JSONEncoder's io.Writer as a single JSON
+// object.  Each Device is a member keyed by its chip name, holding its
+// adapter name and an object for each Sensor, keyed by the Sensor's label.
+// The members of each Sensor's object are those reported by Subfeatures.
+func (e *JSONEncoder) Encode(devices []*Device) error {
+	bw := bufio.NewWriter(e.w)
+
+	// Output is written by hand, as "sensors" does, so that members keep
+	// the order used by libsensors
+	fmt.Fprint(bw, "{")
+	for i, d := range devices {
+		if i > 0 {
+			fmt.Fprint(bw, ",")
+		}
+
+		c := d.ChipName()
+		fmt.Fprintf(bw, "\n   %s:{", jsonString(c.String()))
+
+		var members []string
+		if a := d.adapter(); a != "" {
+			members = append(members, fmt.Sprintf("\n      \"Adapter\": %s", jsonString(a)))
+		}
+
+		for _, s := range sortedSensors(d.Sensors) {
+			sfs := Subfeatures(s)
+			if len(sfs) == 0 {
+				continue
+			}
+
+			values := make([]string, 0, len(sfs))
+			for _, sf := range sfs {
+				values = append(values, fmt.Sprintf("\n         %s: %.3f", jsonString(sf.Name), sf.Value))
+			}
+
+			members = append(members, fmt.Sprintf("\n      %s:{%s\n      }",
+				jsonString(sensorLabel(s)),
+				strings.Join(values, ","),
+			))
+		}
+
+		fmt.Fprintf(bw, "%s\n   }", strings.Join(members, ","))
+	}
+	fmt.Fprint(bw, "\n}\n")
+
+	return bw.Flush()
+}
+
+// ParseJSON parses Devices from the JSON output of the lm-sensors "sensors"
+// command, as produced by "sensors -j".
+//
+// Parsed Devices have no Path, and their ChipName is the chip name that
+// was parsed.  Subfeatures which do not correspond to a Sensor field are
+// ignored, and a Sensor with no input subfeature is marked unavailable, as
+// "sensors" omits readings it cannot get.
+func ParseJSON(r io.Reader) ([]*Device, error) {
+	dec := json.NewDecoder(r)
+
+	// Chips are decoded one at a time, so that Devices keep the order in
+	// which they were written
+	if err := jsonDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var devices []*Device
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name := t.(string)
+
+		var chip map[string]json.RawMessage
+		if err := dec.Decode(&chip); err != nil {
+			return nil, err
+		}
+
+		d, ok := newChipDevice(name)
+		if !ok {
+			return nil, fmt.Errorf("lmsensors: invalid chip name %q", name)
+		}
+
+		if err := d.parseJSON(chip); err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	if err := jsonDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	renameDevices(devices)
+	return devices, nil
+}
+
+// parseJSON parses the Sensors of a Device from its members in the JSON
+// output of "sensors".
+func (d *Device) parseJSON(chip map[string]json.RawMessage) error {
+	labels := make([]string, 0, len(chip))
+	for l := range chip {
+		if l != "Adapter" {
+			labels = append(labels, l)
+			continue
+		}
+
+		var a string
+		if err := json.Unmarshal(chip[l], &a); err != nil {
+			return fmt.Errorf("lmsensors: invalid adapter of chip %q: %v", d.chipName, err)
+		}
+		setAdapter(d, a)
+	}
+	sort.Strings(labels)
+
+	for _, l := range labels {
+		var values map[string]float64
+		if err := json.Unmarshal(chip[l], &values); err != nil {
+			return fmt.Errorf("lmsensors: invalid feature %q of chip %q: %v", l, d.chipName, err)
+		}
+
+		names := make([]string, 0, len(values))
+		for n := range values {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		// A label normally holds the subfeatures of one Sensor, but group
+		// them by name in case it does not
+		var (
+			sensors []Sensor
+			inputs  = make(map[Sensor]bool)
+		)
+		for _, n := range names {
+			fs := strings.SplitN(n, "_", 2)
+			if len(fs) != 2 {
+				return fmt.Errorf("lmsensors: invalid subfeature %q of chip %q", n, d.chipName)
+			}
+
+			if len(sensors) == 0 || sensors[len(sensors)-1].name() != fs[0] {
+				s := newSensor(fs[0])
+				if s == nil {
+					continue
+				}
+
+				sensors = append(sensors, s)
+			}
+
+			s := sensors[len(sensors)-1]
+			if fs[1] == inputAttribute(s) {
+				inputs[s] = true
+			}
+
+			setSubfeature(s, fs[1], values[n])
+		}
+
+		for _, s := range sensors {
+			finishSensor(s, l, inputs[s])
+		}
+		d.Sensors = append(d.Sensors, sensors...)
+	}
+
+	sort.Sort(byName(d.Sensors))
+	return nil
+}
+
+// jsonDelim reads the JSON delimiter delim from dec.
+func jsonDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if t != delim {
+		return fmt.Errorf("lmsensors: expected %q in JSON, but got %v", delim, t)
+	}
+
+	return nil
+}
+
+// jsonString returns s as a JSON string.
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
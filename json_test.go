@@ -0,0 +1,190 @@
+package lmsensors
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewJSONEncoder(&buf).Encode(testDevices(t)); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "sensors-j.golden"))
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if got := buf.Bytes(); !bytes.Equal(want, got) {
+		t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var want bytes.Buffer
+	if err := NewJSONEncoder(&want).Encode(testDevices(t)); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	devices, err := ParseJSON(bytes.NewReader(want.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	var got bytes.Buffer
+	if err := NewJSONEncoder(&got).Encode(devices); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want.Bytes(), got.Bytes())
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		devices []*Device
+		err     string
+	}{
+		{
+			name: "OK",
+			input: `{
+   "nct6775-isa-0290":{
+      "Adapter": "ISA adapter",
+      "Vcore":{
+         "in0_input": 0.880,
+         "in0_min": 0.000,
+         "in0_max": 1.744,
+         "in0_alarm": 0.000,
+         "in0_beep": 0.000
+      },
+      "fan2":{
+         "fan2_min": 0.000,
+         "fan2_alarm": 1.000
+      },
+      "SYSTIN":{
+         "temp1_input": 35.000,
+         "temp1_max": 80.000,
+         "temp1_max_hyst": 75.000,
+         "temp1_alarm": 0.000,
+         "temp1_type": 4.000,
+         "temp1_offset": 0.000
+      }
+   },
+   "lm75-i2c-0-48":{
+      "Adapter": "SMBus I801 adapter at f040",
+      "temp1":{
+         "temp1_input": 31.500
+      }
+   }
+}`,
+			devices: []*Device{
+				{
+					Name:     "nct6775-00",
+					chipName: &ChipName{Prefix: "nct6775", Bus: BusISA, Address: 0x290},
+					Sensors: []Sensor{
+						&FanSensor{
+							Name:  "fan2",
+							Alarm: true,
+							Validity: map[string]Validity{
+								"input": {State: StateUnavailable},
+							},
+							Attributes: map[string]bool{
+								"alarm": true,
+								"min":   true,
+							},
+						},
+						&VoltageSensor{
+							Name:    "in0",
+							Label:   "Vcore",
+							Input:   0.88,
+							Maximum: 1.744,
+							Attributes: map[string]bool{
+								"alarm": true,
+								"beep":  true,
+								"max":   true,
+								"min":   true,
+							},
+						},
+						&TemperatureSensor{
+							Name:  "temp1",
+							Label: "SYSTIN",
+							Input: 35.0,
+							High:  80.0,
+							Type:  TemperatureSensorTypeThermistor,
+							Attributes: map[string]bool{
+								"alarm": true,
+								"max":   true,
+								"type":  true,
+							},
+						},
+					},
+				},
+				{
+					Name:     "lm75-00",
+					Adapter:  "SMBus I801 adapter at f040",
+					chipName: &ChipName{Prefix: "lm75", Bus: BusI2C, Address: 0x48},
+					Sensors: []Sensor{
+						&TemperatureSensor{
+							Name:       "temp1",
+							Input:      31.5,
+							Attributes: map[string]bool{},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "not an object",
+			input: `[]`,
+			err:   `lmsensors: expected "{" in JSON, but got [`,
+		},
+		{
+			name:  "bad chip name",
+			input: `{"nct6775-*":{}}`,
+			err:   `lmsensors: invalid chip name "nct6775-*"`,
+		},
+		{
+			name:  "bad feature",
+			input: `{"nct6775-isa-0290":{"Vcore":1.0}}`,
+			err:   `lmsensors: invalid feature "Vcore" of chip "nct6775-isa-0290": json: cannot unmarshal number into Go value of type map[string]float64`,
+		},
+		{
+			name:  "bad adapter",
+			input: `{"lm75-i2c-0-48":{"Adapter":1}}`,
+			err:   `lmsensors: invalid adapter of chip "lm75-i2c-0-48": json: cannot unmarshal number into Go value of type string`,
+		},
+		{
+			name:  "bad subfeature",
+			input: `{"nct6775-isa-0290":{"Vcore":{"in0":1.0}}}`,
+			err:   `lmsensors: invalid subfeature "in0" of chip "nct6775-isa-0290"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := ParseJSON(strings.NewReader(tt.input))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", tt.err, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if want, got := tt.devices, devices; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Devices:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
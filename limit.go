@@ -0,0 +1,123 @@
+package lmsensors
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// A Limit is a sensor threshold which can be written to a device.
+type Limit string
+
+// All possible Limit constants.  Limits correspond to the suffix of their
+// attribute in the /sys filesystem, e.g. "temp1_max".
+const (
+	LimitMinimum  Limit = "min"
+	LimitMaximum  Limit = "max"
+	LimitCritical Limit = "crit"
+)
+
+// SetLimit sets Limit l of Sensor s to value v, in the units used by the
+// Sensor's fields, such as degrees Celsius or Volts.  Writing limits
+// typically requires root privileges; if permission is denied, a
+// *PermissionError is returned.
+//
+// Drivers may round or clamp written values to the range supported by the
+// hardware, so the written limit is read back from the device, stored in s,
+// and returned.  If a compute statement from the Scanner's Config applies
+// to s, v is converted with its inverse expression before it is written.
+// Fan speeds are not transformed by compute statements.
+//
+// The following limits are supported:
+//   - TemperatureSensor: LimitMaximum (High), LimitCritical (Critical)
+//   - VoltageSensor: LimitMinimum, LimitMaximum
+//   - FanSensor: LimitMinimum
+//   - CurrentSensor: LimitMaximum, LimitCritical
+func (d *Device) SetLimit(s Sensor, l Limit, v float64) (float64, error) {
+	scale, set, ok := limitField(s, l)
+	if !ok {
+		return 0, fmt.Errorf("lmsensors: sensor %q does not support limit %q", s.name(), l)
+	}
+
+	file := s.name() + "_" + string(l)
+
+	// Limits are written in the units of the raw reading, so undo any
+	// compute statement applied to the sensor
+	var cs *ComputeStatement
+	if _, computed := setAttribute(s.name(), string(l)); computed {
+		cs = d.computes[s.name()]
+	}
+
+	if cs != nil {
+		var err error
+		if v, err = cs.To.Eval(v, d.feature); err != nil {
+			return 0, computeError(cs, err)
+		}
+	}
+
+	raw := strconv.FormatInt(int64(math.Round(v*scale)), 10)
+	if err := d.writeAttribute(file, raw); err != nil {
+		return 0, err
+	}
+
+	// Verify the value which was actually applied by the driver
+	out, err := d.readAttribute(file)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(out, 64)
+	if err != nil {
+		return 0, err
+	}
+	f /= scale
+
+	if cs != nil {
+		if f, err = cs.From.Eval(f, d.feature); err != nil {
+			return 0, computeError(cs, err)
+		}
+	}
+
+	set(f)
+	setPresent(s, string(l))
+	return f, nil
+}
+
+// limitField returns the scale factor between a Sensor's field and its
+// raw value in the /sys filesystem, and a function which sets the field
+// for Limit l.  If Sensor s does not support l, limitField returns false.
+func limitField(s Sensor, l Limit) (float64, func(v float64), bool) {
+	switch s := s.(type) {
+	case *CurrentSensor:
+		// Raw current values are scaled by 1000
+		switch l {
+		case LimitMaximum:
+			return 1000, func(v float64) { s.Maximum = v }, true
+		case LimitCritical:
+			return 1000, func(v float64) { s.Critical = v }, true
+		}
+	case *FanSensor:
+		switch l {
+		case LimitMinimum:
+			return 1, func(v float64) { s.Minimum = int(math.Round(v)) }, true
+		}
+	case *TemperatureSensor:
+		// Raw temperature values are scaled by 1000
+		switch l {
+		case LimitMaximum:
+			return 1000, func(v float64) { s.High = v }, true
+		case LimitCritical:
+			return 1000, func(v float64) { s.Critical = v }, true
+		}
+	case *VoltageSensor:
+		// Raw voltage values are scaled by 1000
+		switch l {
+		case LimitMinimum:
+			return 1000, func(v float64) { s.Minimum = v }, true
+		case LimitMaximum:
+			return 1000, func(v float64) { s.Maximum = v }, true
+		}
+	}
+
+	return 0, nil, false
+}
@@ -0,0 +1,154 @@
+package lmsensors
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDeviceSetLimit(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608"
+
+	tests := []struct {
+		name  string
+		file  memoryFile
+		s     Sensor
+		l     Limit
+		v     float64
+		raw   string
+		out   float64
+		check func(t *testing.T, s Sensor)
+		err   bool
+		perm  bool
+	}{
+		{
+			name: "temperature maximum",
+			file: memoryFile{name: dir + "/temp1_max"},
+			s:    &TemperatureSensor{Name: "temp1"},
+			l:    LimitMaximum,
+			v:    75.5,
+			raw:  "75500",
+			out:  75.5,
+			check: func(t *testing.T, s Sensor) {
+				if want, got := 75.5, s.(*TemperatureSensor).High; want != got {
+					t.Fatalf("unexpected High: %v != %v", want, got)
+				}
+			},
+		},
+		{
+			name: "voltage minimum",
+			file: memoryFile{name: dir + "/in0_min"},
+			s:    &VoltageSensor{Name: "in0"},
+			l:    LimitMinimum,
+			v:    0.9,
+			raw:  "900",
+			out:  0.9,
+			check: func(t *testing.T, s Sensor) {
+				if want, got := 0.9, s.(*VoltageSensor).Minimum; want != got {
+					t.Fatalf("unexpected Minimum: %v != %v", want, got)
+				}
+			},
+		},
+		{
+			name: "fan minimum clamped by driver",
+			file: memoryFile{
+				name:    dir + "/fan1_min",
+				onWrite: func(string) string { return "329" },
+			},
+			s:   &FanSensor{Name: "fan1"},
+			l:   LimitMinimum,
+			v:   300,
+			raw: "329",
+			out: 329,
+			check: func(t *testing.T, s Sensor) {
+				if want, got := 329, s.(*FanSensor).Minimum; want != got {
+					t.Fatalf("unexpected Minimum: %v != %v", want, got)
+				}
+			},
+		},
+		{
+			name: "fan minimum rounded",
+			file: memoryFile{
+				name:    dir + "/fan1_min",
+				onWrite: func(string) string { return "599.9" },
+			},
+			s:   &FanSensor{Name: "fan1"},
+			l:   LimitMinimum,
+			v:   600,
+			raw: "599.9",
+			out: 599.9,
+			check: func(t *testing.T, s Sensor) {
+				if want, got := 600, s.(*FanSensor).Minimum; want != got {
+					t.Fatalf("unexpected Minimum: %v != %v", want, got)
+				}
+			},
+		},
+		{
+			name: "current critical",
+			file: memoryFile{name: dir + "/curr1_crit"},
+			s:    &CurrentSensor{Name: "curr1"},
+			l:    LimitCritical,
+			v:    18,
+			raw:  "18000",
+			out:  18,
+		},
+		{
+			name: "unsupported limit",
+			s:    &FanSensor{Name: "fan1"},
+			l:    LimitCritical,
+			err:  true,
+		},
+		{
+			name: "permission denied",
+			file: memoryFile{
+				name:     dir + "/temp1_crit",
+				writeErr: &os.PathError{Op: "open", Path: dir + "/temp1_crit", Err: os.ErrPermission},
+			},
+			s:    &TemperatureSensor{Name: "temp1"},
+			l:    LimitCritical,
+			v:    90,
+			err:  true,
+			perm: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &memoryFilesystem{
+				files: []memoryFile{tt.file},
+			}
+
+			d := &Device{
+				Path: dir,
+				fs:   fs,
+			}
+
+			out, err := d.SetLimit(tt.s, tt.l, tt.v)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				if _, ok := err.(*PermissionError); ok != tt.perm {
+					t.Fatalf("unexpected error type: %#v", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.raw, fs.files[0].contents; want != got {
+				t.Fatalf("unexpected raw value:\n- want: %q\n-  got: %q", want, got)
+			}
+
+			if want, got := tt.out, out; want != got {
+				t.Fatalf("unexpected applied value:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			if tt.check != nil {
+				tt.check(t, tt.s)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package opentsdb writes lmsensors Devices as OpenTSDB telnet-style "put"
+// commands, and streams them to an OpenTSDB server.
+package opentsdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/fields"
+	"github.com/mdlayher/lmsensors/internal/stream"
+)
+
+// An Encoder writes Devices as OpenTSDB "put" commands.
+//
+// Each value of each Sensor is written as a data point of the metric
+// "sensors.kind.field", such as "sensors.temperature.input", tagged with the
+// chip and sensor names, the sensor's label, if any, and the Encoder's Host,
+// if set.  Fields are the subfeatures of each Sensor reported by
+// lmsensors.Subfeatures, and the "duty" and "enable" values of PWMSensors.
+// Alarms and beeps are written as 0 or 1, and values which are not finite,
+// such as NaN, are skipped, as OpenTSDB rejects them.
+type Encoder struct {
+	// Host specifies the value of the "host" tag of each data point,
+	// typically the host name of the machine on which Devices were scanned.
+	// If empty, the tag is omitted.
+	Host string
+
+	// Time specifies the timestamp of each data point.  If zero, the
+	// current time is used.
+	Time time.Time
+
+	w io.Writer
+}
+
+// NewEncoder creates an Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the data points of devices to the Encoder's io.Writer.
+func (e *Encoder) Encode(devices []*lmsensors.Device) error {
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	bw := bufio.NewWriter(e.w)
+	for _, d := range devices {
+		chip := d.ChipName().String()
+
+		for _, s := range d.Sensors {
+			for _, f := range fields.Of(s) {
+				if !f.Finite() {
+					continue
+				}
+
+				tags := map[string]string{
+					"chip":   chip,
+					"host":   e.Host,
+					"label":  f.Label,
+					"sensor": f.Sensor,
+				}
+
+				fmt.Fprintf(bw, "put %s %s %s %s\n",
+					metric(f),
+					ts,
+					strconv.FormatFloat(f.Value, 'f', -1, 64),
+					tagSet(tags),
+				)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// metric returns the metric name of Field f.
+func metric(f fields.Field) string {
+	return "sensors." + sanitize(f.Kind) + "." + sanitize(f.Name)
+}
+
+// tagSet formats tags in key order, omitting tags with empty values.
+func tagSet(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v == "" {
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ss = append(ss, k+"="+sanitize(tags[k]))
+	}
+
+	return strings.Join(ss, " ")
+}
+
+// invalidChars matches characters which are not valid in OpenTSDB metric
+// names and tag values.
+var invalidChars = regexp.MustCompile(`[^-_./A-Za-z0-9]+`)
+
+// sanitize replaces characters in s which are not valid in OpenTSDB metric
+// names and tag values.
+func sanitize(s string) string {
+	return invalidChars.ReplaceAllLiteralString(s, "_")
+}
+
+// A Client streams Devices to an OpenTSDB server over TCP.
+//
+// A Client connects when Devices are first sent, and reconnects after an
+// error.  Data points which cannot be sent are buffered, up to MaxBuffer
+// bytes, and sent with the next Devices.
+type Client struct {
+	// Host specifies the value of the "host" tag, as it does for an
+	// Encoder.
+	Host string
+
+	*stream.Client
+}
+
+// NewClient creates a Client which sends data points to the OpenTSDB server
+// at addr, such as "opentsdb:4242".
+func NewClient(addr string) *Client {
+	return &Client{Client: stream.New(addr)}
+}
+
+// Send sends the data points of devices, with timestamp t.  If the data
+// points cannot be sent, Send returns an error, and they are buffered to be
+// sent with the next Devices.
+func (c *Client) Send(devices []*lmsensors.Device, t time.Time) error {
+	return c.Client.Send(func(w io.Writer) error {
+		e := NewEncoder(w)
+		e.Host = c.Host
+		e.Time = t
+
+		return e.Encode(devices)
+	})
+}
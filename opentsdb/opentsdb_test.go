@@ -0,0 +1,110 @@
+package opentsdb
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/lmsensors"
+	"github.com/mdlayher/lmsensors/internal/lmsensorstest"
+)
+
+func TestEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		devices []*lmsensors.Device
+		want    string
+	}{
+		{
+			name:    "no host",
+			devices: lmsensorstest.Devices(),
+			want: `put sensors.fan.input 1500000000 1130 chip=it8728-isa-0a30 sensor=fan1
+put sensors.fan.min 1500000000 300 chip=it8728-isa-0a30 sensor=fan1
+put sensors.fan.alarm 1500000000 1 chip=it8728-isa-0a30 sensor=fan1
+put sensors.pwm.duty 1500000000 128 chip=it8728-isa-0a30 sensor=pwm1
+put sensors.pwm.enable 1500000000 2 chip=it8728-isa-0a30 sensor=pwm1
+put sensors.temperature.input 1500000000 43.5 chip=it8728-isa-0a30 label=CPU_socket_0 sensor=temp1
+put sensors.temperature.crit 1500000000 100 chip=it8728-isa-0a30 label=CPU_socket_0 sensor=temp1
+put sensors.temperature.alarm 1500000000 0 chip=it8728-isa-0a30 label=CPU_socket_0 sensor=temp1
+put sensors.temperature.crit_alarm 1500000000 0 chip=it8728-isa-0a30 label=CPU_socket_0 sensor=temp1
+put sensors.temperature.type 1500000000 4 chip=it8728-isa-0a30 label=CPU_socket_0 sensor=temp1
+put sensors.power.average 1500000000 12.5 chip=power_meter-acpi-0 sensor=power1
+put sensors.power.average_interval 1500000000 0.5 chip=power_meter-acpi-0 sensor=power1
+`,
+		},
+		{
+			name: "host",
+			host: "web01.example.com",
+			devices: []*lmsensors.Device{{
+				Name: "acpitz-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "put sensors.temperature.input 1500000000 27.8 chip=acpitz-virtual-0 host=web01.example.com sensor=temp1\n",
+		},
+		{
+			name: "sanitized tags",
+			host: "[::1]:4242",
+			devices: []*lmsensors.Device{{
+				Name: "nvme-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Label:      "Sensor 1 (°C)",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "put sensors.temperature.input 1500000000 27.8 chip=nvme-virtual-0 host=_1_4242 label=Sensor_1_C_ sensor=temp1\n",
+		},
+		{
+			name: "not finite",
+			devices: []*lmsensors.Device{{
+				Name: "acpitz-00",
+				Sensors: []lmsensors.Sensor{
+					&lmsensors.TemperatureSensor{
+						Name:       "temp1",
+						Input:      math.NaN(),
+						Attributes: map[string]bool{},
+					},
+					&lmsensors.TemperatureSensor{
+						Name:       "temp2",
+						Input:      math.Inf(-1),
+						Attributes: map[string]bool{},
+					},
+					&lmsensors.TemperatureSensor{
+						Name:       "temp3",
+						Input:      27.8,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+			want: "put sensors.temperature.input 1500000000 27.8 chip=acpitz-virtual-0 sensor=temp3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.Host = tt.host
+			e.Time = time.Unix(1500000000, 0)
+
+			if err := e.Encode(tt.devices); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.want, buf.String(); want != got {
+				t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package lmsensors
+
+// DefaultPlausibility is the Plausibility used by a Scanner when no
+// Plausibility is specified.
+var DefaultPlausibility = &Plausibility{
+	Temperature: Bounds{
+		Minimum: -55,
+		Maximum: 150,
+		// Values commonly reported by disconnected thermal diodes, and
+		// 0xffff read back from a sensor which does not respond
+		Sentinels: []float64{-128, -127, 127.5, 65.535},
+	},
+	Fan: Bounds{
+		Minimum:   0,
+		Maximum:   30000,
+		Sentinels: []float64{65535},
+	},
+}
+
+// A Plausibility specifies the Bounds used to detect implausible readings
+// for each kind of Sensor.  Implausible readings are marked with
+// StateFaulted in the Validity map of their Sensor.
+type Plausibility struct {
+	// Bounds for TemperatureSensor input values, in degrees Celsius.
+	Temperature Bounds
+
+	// Bounds for FanSensor input values, in rotations per minute.
+	Fan Bounds
+
+	// Bounds for VoltageSensor input values, in Volts.
+	Voltage Bounds
+
+	// Bounds for CurrentSensor input values, in Amperes.
+	Current Bounds
+
+	// Bounds for PowerSensor average values, in watts.
+	Power Bounds
+}
+
+// check marks any implausible readings in sensors as faulted.
+func (p *Plausibility) check(sensors []Sensor) {
+	for _, s := range sensors {
+		switch s := s.(type) {
+		case *CurrentSensor:
+			p.Current.check(s, "input", s.Input)
+		case *FanSensor:
+			p.Fan.check(s, "input", float64(s.Input))
+		case *PowerSensor:
+			p.Power.check(s, "average", s.Average)
+		case *TemperatureSensor:
+			p.Temperature.check(s, "input", s.Input)
+		case *VoltageSensor:
+			p.Voltage.check(s, "input", s.Input)
+		}
+	}
+}
+
+// Bounds specify the range of values a sensor can plausibly report.
+type Bounds struct {
+	// The minimum and maximum plausible values.  If Minimum is not less
+	// than Maximum, no range check is performed.
+	Minimum float64
+	Maximum float64
+
+	// Values which are never reported by a working sensor, even if they
+	// fall within the plausible range.
+	Sentinels []float64
+}
+
+// Plausible reports whether or not v is within the Bounds and is not a
+// sentinel value.
+func (b Bounds) Plausible(v float64) bool {
+	if b.Minimum < b.Maximum && (v < b.Minimum || v > b.Maximum) {
+		return false
+	}
+
+	for _, s := range b.Sentinels {
+		if v == s {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check marks the attribute attr of Sensor s as faulted if its value v is
+// not plausible.  Attributes which are already invalid are not checked.
+func (b Bounds) check(s Sensor, attr string, v float64) {
+	if !s.validity()[attr].OK() {
+		return
+	}
+
+	if !b.Plausible(v) {
+		invalidate(s, attr, Validity{State: StateFaulted})
+	}
+}
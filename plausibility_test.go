@@ -0,0 +1,57 @@
+package lmsensors
+
+import (
+	"testing"
+)
+
+func TestBoundsPlausible(t *testing.T) {
+	tests := []struct {
+		name string
+		b    Bounds
+		v    float64
+		ok   bool
+	}{
+		{
+			name: "no bounds",
+			v:    -1000,
+			ok:   true,
+		},
+		{
+			name: "within bounds",
+			b:    DefaultPlausibility.Temperature,
+			v:    45.0,
+			ok:   true,
+		},
+		{
+			name: "below minimum",
+			b:    DefaultPlausibility.Temperature,
+			v:    -60.0,
+		},
+		{
+			name: "above maximum",
+			b:    DefaultPlausibility.Fan,
+			v:    50000,
+		},
+		{
+			name: "sentinel",
+			b:    DefaultPlausibility.Temperature,
+			v:    65.535,
+		},
+		{
+			name: "sentinel without bounds",
+			b: Bounds{
+				Sentinels: []float64{0},
+			},
+			v: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.ok, tt.b.Plausible(tt.v); want != got {
+				t.Fatalf("unexpected plausibility for %v:\n- want: %v\n-  got: %v",
+					tt.v, want, got)
+			}
+		})
+	}
+}
@@ -13,6 +13,10 @@ type PowerSensor struct {
 	// The name of the sensor.
 	Name string
 
+	// A label that describes what the sensor is monitoring.  Label may be
+	// empty.
+	Label string
+
 	// The average electrical power consumption, in watts, indicated
 	// by the sensor.
 	Average float64
@@ -32,10 +36,25 @@ type PowerSensor struct {
 
 	// The serial number of the sensor.
 	SerialNumber string
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
+
+	// The optional attributes provided by the sensor's device, such as
+	// "average_interval", keyed by attribute name.  Optional attributes
+	// which are present are reported even if their values are zero.  If
+	// nil, the interval is only reported if its value is nonzero.
+	Attributes map[string]bool
 }
 
-func (s *PowerSensor) name() string        { return s.Name }
-func (s *PowerSensor) setName(name string) { s.Name = name }
+func (s *PowerSensor) name() string                      { return s.Name }
+func (s *PowerSensor) setName(name string)               { s.Name = name }
+func (s *PowerSensor) validity() map[string]Validity     { return s.Validity }
+func (s *PowerSensor) setValidity(v map[string]Validity) { s.Validity = v }
+func (s *PowerSensor) attributes() map[string]bool       { return s.Attributes }
+func (s *PowerSensor) setAttributes(a map[string]bool)   { s.Attributes = a }
 
 func (s *PowerSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
@@ -57,6 +76,9 @@ func (s *PowerSensor) parse(raw map[string]string) error {
 			}
 
 			s.AverageInterval = d
+			setPresent(s, k)
+		case "label":
+			s.Label = v
 		case "is_battery":
 			s.Battery = v != "0"
 		case "model_number":
@@ -0,0 +1,296 @@
+package lmsensors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// A PWMControl is a handle used to manually control the duty cycle of a
+// PWMSensor.  The original control mode and duty cycle of the output are
+// restored when the PWMControl is closed.
+type PWMControl struct {
+	d *Device
+	s *PWMSensor
+
+	// The state of the output before manual control began.
+	enable PWMEnable
+	duty   int
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// ControlPWM switches the PWM output s to manual control, and returns a
+// PWMControl which can set its duty cycle.  The PWMControl must be closed
+// to return the output to its original control mode.
+//
+// Writing PWM attributes typically requires root privileges; if permission
+// is denied, a *PermissionError is returned.
+func (d *Device) ControlPWM(s *PWMSensor) (*PWMControl, error) {
+	// Remember the current state of the output, as reported by the device
+	// rather than the possibly stale values in s
+	enable, err := d.readPWM(s.Name + "_enable")
+	if err != nil {
+		return nil, err
+	}
+
+	duty, err := d.readPWM(s.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.writeAttribute(s.Name+"_enable", strconv.Itoa(int(PWMEnableManual))); err != nil {
+		return nil, err
+	}
+	s.Enable = PWMEnableManual
+
+	return &PWMControl{
+		d:      d,
+		s:      s,
+		enable: PWMEnable(enable),
+		duty:   duty,
+	}, nil
+}
+
+// Set sets the duty cycle of the PWM output, from 0 (stopped) to 255 (full
+// speed).  Drivers may round the duty cycle to the resolution supported
+// by the hardware, so the applied duty cycle is read back from the device,
+// stored in the PWMSensor, and returned.
+func (c *PWMControl) Set(duty int) (int, error) {
+	if duty < 0 || duty > 255 {
+		return 0, fmt.Errorf("lmsensors: PWM duty cycle %d out of range 0-255", duty)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, fmt.Errorf("lmsensors: PWM control for %q is closed", c.s.Name)
+	}
+
+	if err := c.d.writeAttribute(c.s.Name, strconv.Itoa(duty)); err != nil {
+		return 0, err
+	}
+
+	out, err := c.d.readPWM(c.s.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	c.s.Duty = out
+	return out, nil
+}
+
+// Close restores the original control mode and duty cycle of the PWM
+// output.  Both are restored even if one cannot be written, and the errors
+// are returned together.  If either cannot be restored, the PWMControl
+// remains open, so Close may be called again.  Calling Close more than once
+// after it succeeds has no effect.
+func (c *PWMControl) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	// Restore the duty cycle while still in manual mode, so the output
+	// returns to its original speed if it was also under manual control
+	dutyErr := c.d.writeAttribute(c.s.Name, strconv.Itoa(c.duty))
+	if dutyErr == nil {
+		c.s.Duty = c.duty
+	}
+
+	// The control mode is restored even if the duty cycle is not, so the
+	// output is not left under manual control
+	enableErr := c.d.writeAttribute(c.s.Name+"_enable", strconv.Itoa(int(c.enable)))
+	if enableErr == nil {
+		c.s.Enable = c.enable
+	}
+
+	if err := errors.Join(dutyErr, enableErr); err != nil {
+		return err
+	}
+
+	c.closed = true
+	signalRestorer.remove(c)
+
+	return nil
+}
+
+// FullSpeed ends manual control of the PWM output by running it at full
+// speed, rather than restoring its original control mode.  This is used as
+// a safe fallback when a fan control loop stops.  If the output does not
+// support the full speed control mode, it is left under manual control with
+// the maximum duty cycle.  After FullSpeed is called, Close has no effect.
+func (c *PWMControl) FullSpeed() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	signalRestorer.remove(c)
+
+	err := c.d.writeAttribute(c.s.Name+"_enable", strconv.Itoa(int(PWMEnableFullSpeed)))
+	if err == nil {
+		c.s.Enable = PWMEnableFullSpeed
+		return nil
+	}
+
+	if err := c.d.writeAttribute(c.s.Name, "255"); err != nil {
+		return err
+	}
+	c.s.Duty = 255
+
+	return nil
+}
+
+// RestoreOnSignal closes the PWMControl when the process receives any of
+// the specified signals, or os.Interrupt and SIGTERM if none are specified.
+//
+// A single handler is shared by every PWMControl in the process: when any
+// of the signals registered by any PWMControl is received, all registered
+// outputs are restored, and the signal is then raised again once, so the
+// process can terminate as it would have without the handler installed.
+func (c *PWMControl) RestoreOnSignal(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	signalRestorer.add(c, sigs)
+}
+
+// signalRestorer is the process-wide signal handler used by
+// RestoreOnSignal.
+var signalRestorer = &restorer{raise: raiseSignal}
+
+// A restorer closes registered PWMControls when the process receives a
+// signal.
+type restorer struct {
+	mu       sync.Mutex
+	controls map[*PWMControl]bool
+	signals  map[os.Signal]bool
+	sigC     chan os.Signal
+
+	// Raises a signal again once all PWMControls are closed.
+	raise func(sig os.Signal)
+}
+
+// add registers PWMControl c to be closed when any of sigs is received,
+// installing the signal handler if needed.
+func (r *restorer) add(c *PWMControl, sigs []os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sigC == nil {
+		r.controls = make(map[*PWMControl]bool)
+		r.signals = make(map[os.Signal]bool)
+		r.sigC = make(chan os.Signal, 1)
+
+		go r.wait(r.sigC)
+	}
+
+	r.controls[c] = true
+
+	var added []os.Signal
+	for _, sig := range sigs {
+		if !r.signals[sig] {
+			r.signals[sig] = true
+			added = append(added, sig)
+		}
+	}
+
+	// Notify extends the set of signals delivered to an existing channel
+	if len(added) > 0 {
+		signal.Notify(r.sigC, added...)
+	}
+}
+
+// remove unregisters PWMControl c, removing the signal handler once no
+// PWMControls remain.
+func (r *restorer) remove(c *PWMControl) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.controls[c] {
+		return
+	}
+	delete(r.controls, c)
+
+	if len(r.controls) == 0 {
+		r.stop()
+	}
+}
+
+// stop removes the signal handler.  r.mu must be held.
+func (r *restorer) stop() {
+	// No signals are delivered once Stop returns, so the channel can be
+	// closed to end wait
+	signal.Stop(r.sigC)
+	close(r.sigC)
+
+	r.controls = nil
+	r.signals = nil
+	r.sigC = nil
+}
+
+// wait waits for a signal on sigC, closes all registered PWMControls, and
+// raises the signal again.
+func (r *restorer) wait(sigC chan os.Signal) {
+	sig, ok := <-sigC
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	var controls []*PWMControl
+	if r.sigC == sigC {
+		for c := range r.controls {
+			controls = append(controls, c)
+		}
+
+		// The handler must be removed before the signal is raised again
+		r.stop()
+	}
+	r.mu.Unlock()
+
+	// Each PWMControl unregisters itself as it is closed, so the lock is
+	// not held
+	for _, c := range controls {
+		_ = c.Close()
+	}
+
+	r.raise(sig)
+}
+
+// raiseSignal sends sig to the current process.
+func raiseSignal(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		_ = p.Signal(sig)
+	}
+}
+
+// readPWM reads an integer PWM attribute from the device.
+func (d *Device) readPWM(file string) (int, error) {
+	s, err := d.readAttribute(file)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(s)
+}
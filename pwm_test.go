@@ -0,0 +1,221 @@
+package lmsensors
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDeviceControlPWM(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608"
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{
+				name:     dir + "/pwm1",
+				contents: "96",
+			},
+			{
+				name:     dir + "/pwm1_enable",
+				contents: "2",
+			},
+		},
+	}
+
+	d := &Device{
+		Path: dir,
+		fs:   fs,
+	}
+
+	s := &PWMSensor{
+		Name:   "pwm1",
+		Enable: PWMEnableAutomatic,
+		Duty:   96,
+	}
+
+	c, err := d.ControlPWM(s)
+	if err != nil {
+		t.Fatalf("failed to control PWM: %v", err)
+	}
+
+	if want, got := "1", fs.files[1].contents; want != got {
+		t.Fatalf("unexpected pwm1_enable after ControlPWM: %q != %q", want, got)
+	}
+
+	duty, err := c.Set(200)
+	if err != nil {
+		t.Fatalf("failed to set duty cycle: %v", err)
+	}
+
+	if want, got := 200, duty; want != got {
+		t.Fatalf("unexpected applied duty cycle: %v != %v", want, got)
+	}
+	if want, got := 200, s.Duty; want != got {
+		t.Fatalf("unexpected sensor duty cycle: %v != %v", want, got)
+	}
+
+	if _, err := c.Set(256); err == nil {
+		t.Fatal("expected an error for out of range duty cycle, but none occurred")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close PWM control: %v", err)
+	}
+
+	if want, got := "96", fs.files[0].contents; want != got {
+		t.Fatalf("unexpected restored pwm1: %q != %q", want, got)
+	}
+	if want, got := "2", fs.files[1].contents; want != got {
+		t.Fatalf("unexpected restored pwm1_enable: %q != %q", want, got)
+	}
+	if want, got := PWMEnableAutomatic, s.Enable; want != got {
+		t.Fatalf("unexpected restored sensor mode: %v != %v", want, got)
+	}
+
+	if _, err := c.Set(128); err == nil {
+		t.Fatal("expected an error after Close, but none occurred")
+	}
+}
+
+func TestPWMControlCloseRetry(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608"
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{
+				name:     dir + "/pwm1",
+				contents: "96",
+			},
+			{
+				name:     dir + "/pwm1_enable",
+				contents: "2",
+			},
+		},
+	}
+
+	d := &Device{
+		Path: dir,
+		fs:   fs,
+	}
+
+	c, err := d.ControlPWM(&PWMSensor{Name: "pwm1"})
+	if err != nil {
+		t.Fatalf("failed to control PWM: %v", err)
+	}
+
+	if _, err := c.Set(200); err != nil {
+		t.Fatalf("failed to set duty cycle: %v", err)
+	}
+
+	// The control mode is restored even if the duty cycle cannot be
+	fs.files[0].writeErr = syscall.EIO
+	if err := c.Close(); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	if want, got := "2", fs.files[1].contents; want != got {
+		t.Fatalf("unexpected restored pwm1_enable: %q != %q", want, got)
+	}
+
+	// The PWMControl remains open, so Close can be retried
+	fs.files[0].writeErr = nil
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close PWM control: %v", err)
+	}
+
+	if want, got := "96", fs.files[0].contents; want != got {
+		t.Fatalf("unexpected restored pwm1: %q != %q", want, got)
+	}
+
+	fs.files[0].writeErr = syscall.EIO
+	if err := c.Close(); err != nil {
+		t.Fatalf("unexpected error closing PWM control again: %v", err)
+	}
+}
+
+func TestRestorerRestoresAllControls(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.2608"
+
+	fs := &memoryFilesystem{
+		files: []memoryFile{
+			{
+				name:     dir + "/pwm1",
+				contents: "96",
+			},
+			{
+				name:     dir + "/pwm1_enable",
+				contents: "2",
+			},
+			{
+				name:     dir + "/pwm2",
+				contents: "64",
+			},
+			{
+				name:     dir + "/pwm2_enable",
+				contents: "2",
+			},
+		},
+	}
+
+	d := &Device{
+		Path: dir,
+		fs:   fs,
+	}
+
+	raised := make(chan os.Signal, 2)
+	r := &restorer{
+		raise: func(sig os.Signal) { raised <- sig },
+	}
+
+	for _, name := range []string{"pwm1", "pwm2"} {
+		c, err := d.ControlPWM(&PWMSensor{Name: name})
+		if err != nil {
+			t.Fatalf("failed to control PWM: %v", err)
+		}
+
+		if _, err := c.Set(255); err != nil {
+			t.Fatalf("failed to set duty cycle: %v", err)
+		}
+
+		r.add(c, []os.Signal{syscall.SIGUSR1})
+	}
+
+	// Deliver the signal to the handler directly, as the signal package would
+	r.mu.Lock()
+	r.sigC <- syscall.SIGUSR1
+	r.mu.Unlock()
+
+	select {
+	case sig := <-raised:
+		if want, got := syscall.SIGUSR1, sig; want != got {
+			t.Fatalf("unexpected raised signal: %v != %v", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for signal to be raised")
+	}
+
+	// All outputs are restored before the signal is raised
+	for _, f := range []struct {
+		name, want string
+	}{
+		{name: "/pwm1", want: "96"},
+		{name: "/pwm1_enable", want: "2"},
+		{name: "/pwm2", want: "64"},
+		{name: "/pwm2_enable", want: "2"},
+	} {
+		if want, got := f.want, fs.contents(dir+f.name); want != got {
+			t.Fatalf("unexpected restored %s: %q != %q", f.name, want, got)
+		}
+	}
+
+	select {
+	case sig := <-raised:
+		t.Fatalf("signal raised more than once: %v", sig)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if r.sigC != nil {
+		t.Fatal("signal handler was not removed")
+	}
+}
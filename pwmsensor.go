@@ -0,0 +1,64 @@
+package lmsensors
+
+import (
+	"strconv"
+)
+
+// A PWMEnable is a value that indicates the control mode of a PWMSensor.
+type PWMEnable int
+
+// All possible PWMEnable constants.  Values greater than
+// PWMEnableAutomatic select chip-specific automatic control modes.
+const (
+	PWMEnableFullSpeed PWMEnable = 0
+	PWMEnableManual    PWMEnable = 1
+	PWMEnableAutomatic PWMEnable = 2
+)
+
+var _ Sensor = &PWMSensor{}
+
+// A PWMSensor is a Sensor that reports the pulse width modulation output
+// used to drive a fan.  Use Device.ControlPWM to change its duty cycle.
+type PWMSensor struct {
+	// The name of the sensor.
+	Name string
+
+	// The control mode of the output.
+	Enable PWMEnable
+
+	// The duty cycle of the output, from 0 (stopped) to 255 (full speed).
+	Duty int
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "enable".  The duty cycle attribute is named
+	// with an empty string.  Attributes which are not present were read
+	// successfully.
+	Validity map[string]Validity
+}
+
+func (s *PWMSensor) name() string                      { return s.Name }
+func (s *PWMSensor) setName(name string)               { s.Name = name }
+func (s *PWMSensor) validity() map[string]Validity     { return s.Validity }
+func (s *PWMSensor) setValidity(v map[string]Validity) { s.Validity = v }
+
+func (s *PWMSensor) parse(raw map[string]string) error {
+	for k, v := range raw {
+		switch k {
+		case "", "enable":
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+
+			switch k {
+			// Duty cycle has no attribute suffix, e.g. "pwm1"
+			case "":
+				s.Duty = i
+			case "enable":
+				s.Enable = PWMEnable(i)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package lmsensors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A RawEncoder writes Devices in the raw format of the lm-sensors "sensors"
+// command, as produced by "sensors -u".
+type RawEncoder struct {
+	w io.Writer
+}
+
+// NewRawEncoder creates a RawEncoder which writes to w.
+func NewRawEncoder(w io.Writer) *RawEncoder {
+	return &RawEncoder{w: w}
+}
+
+// Encode writes devices to the RawEncoder's io.Writer.  Each Device is
+// written as a chip name header and an adapter line, followed by a label
+// line for each Sensor with one indented line per subfeature, and a blank
+// line.  The subfeatures of each Sensor are those reported by Subfeatures.
+func (e *RawEncoder) Encode(devices []*Device) error {
+	bw := bufio.NewWriter(e.w)
+
+	for _, d := range devices {
+		fmt.Fprintln(bw, d.ChipName())
+		if a := d.adapter(); a != "" {
+			fmt.Fprintf(bw, "Adapter: %s\n", a)
+		}
+
+		for _, s := range sortedSensors(d.Sensors) {
+			sfs := Subfeatures(s)
+			if len(sfs) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(bw, "%s:\n", sensorLabel(s))
+			for _, sf := range sfs {
+				fmt.Fprintf(bw, "  %s: %.3f\n", sf.Name, sf.Value)
+			}
+		}
+
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// ParseRaw parses Devices from the raw output of the lm-sensors "sensors"
+// command, as produced by "sensors -u".
+//
+// Parsed Devices have no Path, and their ChipName is the chip name that
+// was parsed.  Optional subfeatures, such as limits, are recorded in the
+// Attributes of their Sensor, so that values of zero are kept.  Subfeatures
+// which do not correspond to a Sensor field are ignored, and a Sensor with
+// no input subfeature is marked unavailable, as "sensors" omits readings it
+// cannot get.  Error messages that "sensors" interleaves with its output
+// are skipped.
+func ParseRaw(r io.Reader) ([]*Device, error) {
+	var (
+		devices []*Device
+		d       *Device
+		label   string
+		s       Sensor
+		input   bool
+		n       int
+	)
+
+	// flush finishes the current Sensor, if any
+	flush := func() {
+		if s == nil {
+			return
+		}
+
+		finishSensor(s, label, input)
+		d.Sensors = append(d.Sensors, s)
+		s = nil
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		n++
+		line := strings.TrimRight(sc.Text(), " \t\r")
+
+		switch {
+		case strings.HasPrefix(line, "ERROR:"):
+			continue
+		case line == "":
+			flush()
+			d = nil
+			continue
+		case d == nil:
+			var ok bool
+			if d, ok = newChipDevice(line); !ok {
+				return nil, rawError(n, "invalid chip name %q", line)
+			}
+			devices = append(devices, d)
+			continue
+		case strings.HasPrefix(line, "Adapter: "):
+			setAdapter(d, strings.TrimPrefix(line, "Adapter: "))
+			continue
+		}
+
+		// Subfeatures are indented, while labels of features are not
+		if line[0] != ' ' && line[0] != '\t' {
+			if !strings.HasSuffix(line, ":") {
+				return nil, rawError(n, "unexpected line %q", line)
+			}
+
+			flush()
+			label = strings.TrimSuffix(line, ":")
+			continue
+		}
+
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(fields) != 2 {
+			return nil, rawError(n, "invalid subfeature %q", line)
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, rawError(n, "invalid value for subfeature %q", fields[0])
+		}
+
+		// Subfeature names are the feature name and an attribute, such as
+		// "temp1_crit_alarm"
+		name := strings.SplitN(fields[0], "_", 2)
+		if len(name) != 2 {
+			return nil, rawError(n, "invalid subfeature %q", fields[0])
+		}
+
+		if s == nil || s.name() != name[0] {
+			// A new feature under the same label is unusual, but keep its
+			// Sensor separate from the previous one
+			if s != nil {
+				flush()
+			}
+
+			s, input = newSensor(name[0]), false
+			if s == nil {
+				continue
+			}
+		}
+
+		if name[1] == inputAttribute(s) {
+			input = true
+		}
+
+		setSubfeature(s, name[1], v)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	for _, d := range devices {
+		sort.Sort(byName(d.Sensors))
+	}
+	renameDevices(devices)
+
+	return devices, nil
+}
+
+// newChipDevice creates a Device for a chip name in the output of the
+// "sensors" command.  If the chip name is invalid, newChipDevice returns
+// false.
+func newChipDevice(name string) (*Device, bool) {
+	// Chip names in output are never patterns
+	c, err := ParseChipName(name)
+	if err != nil || c.Prefix == "*" || c.BusNumber == ChipAny || c.Address == ChipAny {
+		return nil, false
+	}
+
+	return &Device{
+		Name:     c.Prefix,
+		chipName: &c,
+	}, true
+}
+
+// setAdapter sets the adapter name of Device d from the output of the
+// "sensors" command.  Only the names of i2c adapters are kept, as the names
+// of other adapters are known from the bus type.
+func setAdapter(d *Device, name string) {
+	if d.ChipName().Bus == BusI2C {
+		d.Adapter = name
+	}
+}
+
+// finishSensor completes Sensor s parsed from the output of the "sensors"
+// command, by setting its label and marking its reading unavailable if
+// input is false.
+func finishSensor(s Sensor, label string, input bool) {
+	if label != s.name() {
+		if set, ok := labelField(s); ok {
+			set(label)
+		}
+	}
+
+	if _, ok := s.(*IntrusionSensor); !ok && !input {
+		invalidate(s, inputAttribute(s), Validity{State: StateUnavailable})
+	}
+}
+
+// inputAttribute returns the name of the attribute which holds the reading
+// of Sensor s.
+func inputAttribute(s Sensor) string {
+	if _, ok := s.(*PowerSensor); ok {
+		return "average"
+	}
+
+	return "input"
+}
+
+// rawError creates an error for line n of raw "sensors" output.
+func rawError(n int, format string, v ...interface{}) error {
+	return fmt.Errorf("lmsensors: line %d: %s", n, fmt.Sprintf(format, v...))
+}
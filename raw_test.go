@@ -0,0 +1,261 @@
+package lmsensors
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewRawEncoder(&buf).Encode(testDevices(t)); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "sensors-u.golden"))
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if got := buf.Bytes(); !bytes.Equal(want, got) {
+		t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+	}
+}
+
+func TestRawRoundTrip(t *testing.T) {
+	var want bytes.Buffer
+	if err := NewRawEncoder(&want).Encode(testDevices(t)); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	devices, err := ParseRaw(bytes.NewReader(want.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	var got bytes.Buffer
+	if err := NewRawEncoder(&got).Encode(devices); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want.Bytes(), got.Bytes())
+	}
+}
+
+func TestRawZeroLimits(t *testing.T) {
+	devices := []*Device{{
+		Name:     "nct6775-00",
+		chipName: &ChipName{Prefix: "nct6775", Bus: BusISA, Address: 0x290},
+		Sensors: []Sensor{
+			&VoltageSensor{
+				Name:    "in0",
+				Label:   "Vcore",
+				Input:   0.88,
+				Maximum: 1.744,
+				Attributes: map[string]bool{
+					"alarm": true,
+					"max":   true,
+					"min":   true,
+				},
+			},
+		},
+	}}
+
+	const want = `nct6775-isa-0290
+Adapter: ISA adapter
+Vcore:
+  in0_input: 0.880
+  in0_min: 0.000
+  in0_max: 1.744
+  in0_alarm: 0.000
+
+`
+
+	var buf bytes.Buffer
+	if err := NewRawEncoder(&buf).Encode(devices); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	if got := buf.String(); want != got {
+		t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+	}
+
+	parsed, err := ParseRaw(strings.NewReader(want))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if !reflect.DeepEqual(devices, parsed) {
+		t.Fatalf("unexpected Devices:\n- want: %v\n-  got: %v", devices, parsed)
+	}
+}
+
+func TestParseRaw(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		devices []*Device
+		err     string
+	}{
+		{
+			name: "OK",
+			input: strings.Join([]string{
+				"nct6775-isa-0290",
+				"Adapter: ISA adapter",
+				"Vcore:",
+				"  in0_input: 0.880",
+				"  in0_min: 0.000",
+				"  in0_max: 1.744",
+				"  in0_alarm: 0.000",
+				"  in0_beep: 0.000",
+				"fan2:",
+				"  fan2_min: 0.000",
+				"  fan2_alarm: 1.000",
+				"ERROR: Can't get value of subfeature fan2_input: Can't read",
+				"SYSTIN:",
+				"  temp1_input: 35.000",
+				"  temp1_max: 80.000",
+				"  temp1_max_hyst: 75.000",
+				"  temp1_alarm: 0.000",
+				"  temp1_type: 4.000",
+				"  temp1_offset: 0.000",
+				"intrusion0:",
+				"  intrusion0_alarm: 1.000",
+				"",
+				"nct6775-isa-0a00",
+				"Adapter: ISA adapter",
+				"power1:",
+				"  power1_average: 12.500",
+				"  power1_average_interval: 0.500",
+				"",
+			}, "\n"),
+			devices: []*Device{
+				{
+					Name:     "nct6775-00",
+					chipName: &ChipName{Prefix: "nct6775", Bus: BusISA, Address: 0x290},
+					Sensors: []Sensor{
+						&FanSensor{
+							Name:  "fan2",
+							Alarm: true,
+							Validity: map[string]Validity{
+								"input": {State: StateUnavailable},
+							},
+							Attributes: map[string]bool{
+								"alarm": true,
+								"min":   true,
+							},
+						},
+						&VoltageSensor{
+							Name:    "in0",
+							Label:   "Vcore",
+							Input:   0.88,
+							Maximum: 1.744,
+							Attributes: map[string]bool{
+								"alarm": true,
+								"beep":  true,
+								"max":   true,
+								"min":   true,
+							},
+						},
+						&IntrusionSensor{
+							Name:  "intrusion0",
+							Alarm: true,
+						},
+						&TemperatureSensor{
+							Name:  "temp1",
+							Label: "SYSTIN",
+							Input: 35.0,
+							High:  80.0,
+							Type:  TemperatureSensorTypeThermistor,
+							Attributes: map[string]bool{
+								"alarm": true,
+								"max":   true,
+								"type":  true,
+							},
+						},
+					},
+				},
+				{
+					Name:     "nct6775-01",
+					chipName: &ChipName{Prefix: "nct6775", Bus: BusISA, Address: 0xa00},
+					Sensors: []Sensor{
+						&PowerSensor{
+							Name:            "power1",
+							Average:         12.5,
+							AverageInterval: 500 * time.Millisecond,
+							Attributes: map[string]bool{
+								"average_interval": true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SCSI drive",
+			input: strings.Join([]string{
+				"drivetemp-scsi-0-0",
+				"Adapter: SCSI adapter",
+				"temp1:",
+				"  temp1_input: 33.000",
+				"",
+			}, "\n"),
+			devices: []*Device{{
+				Name:     "drivetemp-00",
+				chipName: &ChipName{Prefix: "drivetemp", Bus: BusSCSI},
+				Sensors: []Sensor{
+					&TemperatureSensor{
+						Name:       "temp1",
+						Input:      33.0,
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+		},
+		{
+			name:  "bad chip name",
+			input: "nct6775-isa-*\n",
+			err:   `lmsensors: line 1: invalid chip name "nct6775-isa-*"`,
+		},
+		{
+			name:  "bad label",
+			input: "nct6775-isa-0290\nVcore\n",
+			err:   `lmsensors: line 2: unexpected line "Vcore"`,
+		},
+		{
+			name:  "bad subfeature",
+			input: "nct6775-isa-0290\nVcore:\n  in0: 0.880\n",
+			err:   `lmsensors: line 3: invalid subfeature "in0"`,
+		},
+		{
+			name:  "bad value",
+			input: "nct6775-isa-0290\nVcore:\n  in0_input: foo\n",
+			err:   `lmsensors: line 3: invalid value for subfeature "in0_input"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := ParseRaw(strings.NewReader(tt.input))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", tt.err, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			if want, got := tt.devices, devices; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Devices:\n- want: %v\n-  got: %v", want, got)
+			}
+		})
+	}
+}
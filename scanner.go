@@ -6,100 +6,313 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // A filesystem is an interface to a filesystem, used for testing.
 type filesystem interface {
 	ReadFile(filename string) (string, error)
+	WriteFile(filename, data string) error
 	Readlink(name string) (string, error)
 	Stat(name string) (os.FileInfo, error)
 	Walk(root string, walkFn filepath.WalkFunc) error
 }
 
+// A clock is an interface to the system clock, used for testing.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
 // A Scanner scans for Devices, so data can be read from their Sensors.
 type Scanner struct {
-	fs filesystem
+	// Plausibility specifies the bounds used to mark implausible sensor
+	// readings as faulted.  If nil, DefaultPlausibility is used.
+	Plausibility *Plausibility
+
+	// Config specifies an lm-sensors configuration whose label, ignore,
+	// and compute statements are applied to the Sensors of matching
+	// Devices.
+	Config *Config
+
+	// Board specifies a board profile, which is applied to Devices that are
+	// not matched by any chip block in Config.  If nil, the profile for the
+	// machine is loaded from BoardProfiles on each scan.
+	Board *Config
+
+	// BoardProfiles specifies the directory from which board profiles are
+	// loaded when Board is nil, as LoadBoardProfile does.  New sets
+	// BoardProfiles to DefaultBoardProfiles.  If empty, no board profile is
+	// loaded.
+	BoardProfiles string
+
+	// Defaults specifies chip defaults which are applied to Devices that
+	// are not matched by any chip block in Config or Board.  If nil,
+	// DefaultConfig is used.
+	Defaults *Config
+
+	// NoDefaults specifies that no chip defaults are applied, so Sensors
+	// of Devices which are not matched by Config or Board are returned as
+	// reported by their drivers.
+	NoDefaults bool
+
+	fs    filesystem
+	clock clock
 }
 
+// DefaultBoardProfiles is the directory from which a Scanner created by
+// New loads board profiles.
+const DefaultBoardProfiles = "/etc/sensors/boards"
+
 // New creates a new Scanner.
 func New() *Scanner {
 	return &Scanner{
-		fs: &systemFilesystem{},
+		BoardProfiles: DefaultBoardProfiles,
+
+		fs:    &systemFilesystem{},
+		clock: &systemClock{},
 	}
 }
 
 // Scan scans for Devices and their Sensors.
 func (s *Scanner) Scan() ([]*Device, error) {
+	results, err := s.ScanResults()
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*Device, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+
+		devices = append(devices, r.Device)
+	}
+
+	return devices, nil
+}
+
+// A ScanResult is the result of scanning a single Device.
+type ScanResult struct {
+	// The Device which was scanned.  If Err is non-nil, the Device's name
+	// and paths are set, but its Sensors may be incomplete.
+	Device *Device
+
+	// The time taken to scan the Device.
+	Duration time.Duration
+
+	// Any error which occurred while scanning the Device.
+	Err error
+}
+
+// ScanResults scans for Devices and their Sensors, as Scan does, and
+// returns the result of scanning each Device.
+//
+// An error is only returned if Devices cannot be found or the board profile
+// cannot be loaded; errors which occur while scanning individual Devices
+// are reported in each ScanResult.
+func (s *Scanner) ScanResults() ([]*ScanResult, error) {
 	// Determine common device locations in Linux /sys filesystem.
-	paths, err := s.detectDevicePaths()
+	devices, err := s.detectDevices()
 	if err != nil {
 		return nil, err
 	}
 
-	var devices []*Device
-	for _, p := range paths {
-		d := &Device{}
-		raw := make(map[string]map[string]string, 0)
-
-		// Walk filesystem paths to fetch devices and sensors
-		err := s.fs.Walk(p, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
+	dmi := s.readDMI()
 
-			// Skip directories and anything that isn't a regular file
-			if info.IsDir() || !info.Mode().IsRegular() {
-				return nil
-			}
+	board := s.Board
+	if board == nil && s.BoardProfiles != "" {
+		if board, err = s.loadBoardProfile(dmi, s.BoardProfiles); err != nil {
+			return nil, err
+		}
+	}
 
-			// Skip some files that can't be read or don't provide useful
-			// sensor information
-			file := filepath.Base(path)
-			if shouldSkip(file) {
-				return nil
-			}
+	results := make([]*ScanResult, 0, len(devices))
+	for _, d := range devices {
+		d.DMI = dmi
+		d.fs = s.fs
+		d.clock = s.clock
+
+		start := s.now()
+		err := s.scanDevice(d)
+		results = append(results, &ScanResult{
+			Device:   d,
+			Duration: s.now().Sub(start),
+			Err:      err,
+		})
+	}
 
-			s, err := s.fs.ReadFile(path)
-			if err != nil {
-				return nil
-			}
+	renameDevices(devices)
 
-			switch file {
-			// Found name of device
-			case "name":
-				d.Name = s
-			}
+	// Configuration is matched against chip names, so it can only be
+	// applied once all devices are named
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+
+		c := s.config(r.Device, board)
+		if c == nil {
+			continue
+		}
+
+		start := s.now()
+		r.Err = c.apply(r.Device)
+		r.Duration += s.now().Sub(start)
+	}
 
-			// Sensor names in format "sensor#_foo", e.g. "temp1_input"
-			fs := strings.SplitN(file, "_", 2)
-			if len(fs) != 2 {
+	return results, nil
+}
+
+// scanDevice reads the Sensors of Device d.
+func (s *Scanner) scanDevice(d *Device) error {
+	raw := make(map[string]map[string]string, 0)
+	invalid := make(map[string]map[string]Validity, 0)
+
+	// Walk filesystem paths to fetch devices and sensors
+	err := s.fs.Walk(d.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories and anything that isn't a regular file
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		// Skip some files that can't be read or don't provide useful
+		// sensor information
+		file := filepath.Base(path)
+		if shouldSkip(file) {
+			return nil
+		}
+
+		// Sensor names in format "sensor#_foo", e.g. "temp1_input".
+		// PWM duty cycles have no attribute suffix, e.g. "pwm1".
+		fs := strings.SplitN(file, "_", 2)
+		if len(fs) == 1 && strings.HasPrefix(file, "pwm") {
+			fs = append(fs, "")
+		}
+
+		s, err := s.fs.ReadFile(path)
+		if err != nil {
+			// Sensor attributes which exist but cannot be read are
+			// recorded, so they are not mistaken for a zero value
+			v, ok := newValidity(err)
+			if !ok || len(fs) != 2 {
 				return nil
 			}
 
-			// Gather sensor data into map for later processing
-			if _, ok := raw[fs[0]]; !ok {
-				raw[fs[0]] = make(map[string]string, 0)
+			if _, ok := invalid[fs[0]]; !ok {
+				invalid[fs[0]] = make(map[string]Validity, 0)
 			}
 
-			raw[fs[0]][fs[1]] = s
+			invalid[fs[0]][fs[1]] = v
 			return nil
-		})
-		if err != nil {
-			return nil, err
 		}
 
-		// Parse all possible sensors from raw data
-		sensors, err := parseSensors(raw)
-		if err != nil {
-			return nil, err
+		switch file {
+		// Found name of device
+		case "name":
+			d.Name = s
 		}
 
-		d.Sensors = sensors
-		devices = append(devices, d)
+		if len(fs) != 2 {
+			return nil
+		}
+
+		// Gather sensor data into map for later processing
+		if _, ok := raw[fs[0]]; !ok {
+			raw[fs[0]] = make(map[string]string, 0)
+		}
+
+		raw[fs[0]][fs[1]] = s
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	renameDevices(devices)
-	return devices, nil
+	// Parse all possible sensors from raw data
+	sensors, err := parseSensors(raw, invalid)
+	if err != nil {
+		return err
+	}
+
+	s.plausibility().check(sensors)
+
+	d.Sensors = sensors
+	d.Adapter = s.i2cAdapter(d)
+	return nil
+}
+
+// i2cAdapterPath is the location of i2c adapters in the /sys filesystem.
+const i2cAdapterPath = "/sys/class/i2c-adapter"
+
+// i2cAdapter reads the name of the i2c adapter of Device d.  If d is not
+// on an i2c bus, or the name cannot be read, i2cAdapter returns the empty
+// string.
+func (s *Scanner) i2cAdapter(d *Device) string {
+	c := d.ChipName()
+	if c.Bus != BusI2C {
+		return ""
+	}
+
+	name, err := s.fs.ReadFile(filepath.Join(i2cAdapterPath, fmt.Sprintf("i2c-%d", c.BusNumber), "name"))
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// config returns the Config applied to Device d: the Scanner's Config or
+// board profile if either has statements for d, in that order, or its
+// defaults otherwise.  If no Config applies, config returns nil.
+func (s *Scanner) config(d *Device, board *Config) *Config {
+	for _, c := range []*Config{s.Config, board} {
+		if c != nil && c.matches(d) {
+			return c
+		}
+	}
+
+	switch {
+	case s.NoDefaults:
+		return nil
+	case s.Defaults == nil:
+		return DefaultConfig
+	}
+
+	return s.Defaults
+}
+
+// now returns the current time from the Scanner's clock.
+func (s *Scanner) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+
+	return s.clock.Now()
+}
+
+// after waits for duration d to elapse on the Scanner's clock, and then
+// sends the current time on the returned channel.
+func (s *Scanner) after(d time.Duration) <-chan time.Time {
+	if s.clock == nil {
+		return time.After(d)
+	}
+
+	return s.clock.After(d)
+}
+
+// plausibility returns the Plausibility used by the Scanner.
+func (s *Scanner) plausibility() *Plausibility {
+	if s.Plausibility == nil {
+		return DefaultPlausibility
+	}
+
+	return s.Plausibility
 }
 
 // renameDevices renames devices in place to prevent duplicate device names,
@@ -117,12 +330,12 @@ func renameDevices(devices []*Device) {
 	}
 }
 
-// detectDevicePaths performs a filesystem walk to paths where devices may
-// reside on Linux.
-func (s *Scanner) detectDevicePaths() ([]string, error) {
+// detectDevices performs a filesystem walk to paths where devices may
+// reside on Linux, and returns Devices with their paths populated.
+func (s *Scanner) detectDevices() ([]*Device, error) {
 	const lookPath = "/sys/class/hwmon"
 
-	var paths []string
+	var devices []*Device
 	err := s.fs.Walk(lookPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -146,7 +359,19 @@ func (s *Scanner) detectDevicePaths() ([]string, error) {
 			return err
 		}
 		if err == nil && fi.Mode().IsRegular() {
-			paths = append(paths, dest)
+			d := &Device{
+				Path:  dest,
+				Hwmon: filepath.Base(path),
+			}
+
+			// Devices backed by hardware link to it with a symlink called
+			// device, but virtual devices have no such link
+			if device, err := s.fs.Readlink(filepath.Join(dest, "device")); err == nil {
+				d.DevicePath = filepath.Join(dest, filepath.Clean(device))
+				d.subsystem = s.subsystem(d.DevicePath)
+			}
+
+			devices = append(devices, d)
 			return nil
 		}
 
@@ -182,11 +407,28 @@ func (s *Scanner) detectDevicePaths() ([]string, error) {
 			return err
 		}
 
-		paths = append(paths, dest)
+		devices = append(devices, &Device{
+			Path:       dest,
+			Hwmon:      filepath.Base(path),
+			DevicePath: dest,
+			subsystem:  s.subsystem(dest),
+		})
 		return nil
 	})
 
-	return paths, err
+	return devices, err
+}
+
+// subsystem reads the name of the subsystem of the hardware at path, such
+// as "mdio_bus", from its subsystem symlink.  If the link cannot be read,
+// subsystem returns the empty string.
+func (s *Scanner) subsystem(path string) string {
+	sub, err := s.fs.Readlink(filepath.Join(path, "subsystem"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(sub)
 }
 
 // shouldSkip indicates if a given filename should be skipped during the
@@ -225,8 +467,31 @@ func (fs *systemFilesystem) ReadFile(filename string) (string, error) {
 	return strings.TrimSpace(string(b)), nil
 }
 
+func (fs *systemFilesystem) WriteFile(filename, data string) error {
+	// sysfs attributes must already exist, and are never created
+	f, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
 func (fs *systemFilesystem) Readlink(name string) (string, error)  { return os.Readlink(name) }
 func (fs *systemFilesystem) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
 func (fs *systemFilesystem) Walk(root string, walkFn filepath.WalkFunc) error {
 	return filepath.Walk(root, walkFn)
 }
+
+var _ clock = &systemClock{}
+
+// A systemClock is a clock which uses the host's system clock.
+type systemClock struct{}
+
+func (c *systemClock) Now() time.Time                         { return time.Now() }
+func (c *systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -87,7 +88,10 @@ func TestScannerScan(t *testing.T) {
 				},
 			},
 			devices: []*Device{{
-				Name: "power_meter-00",
+				Name:       "power_meter-00",
+				Path:       "/sys/devices/LNXSYSTM:00/device:00/ACPI0000:00",
+				Hwmon:      "hwmon0",
+				DevicePath: "/sys/devices/LNXSYSTM:00/device:00/ACPI0000:00",
 				Sensors: []Sensor{
 					&PowerSensor{
 						Name:            "power1",
@@ -97,6 +101,9 @@ func TestScannerScan(t *testing.T) {
 						ModelNumber:     "Intel(R) Node Manager",
 						OEMInfo:         "Meter measures total domain",
 						SerialNumber:    "",
+						Attributes: map[string]bool{
+							"average_interval": true,
+						},
 					},
 				},
 			}},
@@ -141,13 +148,18 @@ func TestScannerScan(t *testing.T) {
 				},
 			},
 			devices: []*Device{{
-				Name: "acpitz-00",
+				Name:  "acpitz-00",
+				Path:  "/sys/devices/virtual/hwmon/hwmon0",
+				Hwmon: "hwmon0",
 				Sensors: []Sensor{
 					&TemperatureSensor{
 						Name:          "temp1",
 						Input:         27.8,
 						Critical:      105.0,
 						CriticalAlarm: false,
+						Attributes: map[string]bool{
+							"crit": true,
+						},
 					},
 				},
 			}},
@@ -235,7 +247,10 @@ func TestScannerScan(t *testing.T) {
 				},
 			},
 			devices: []*Device{{
-				Name: "coretemp-00",
+				Name:       "coretemp-00",
+				Path:       "/sys/devices/platform/coretemp.0",
+				Hwmon:      "hwmon1",
+				DevicePath: "/sys/devices/platform/coretemp.0",
 				Sensors: []Sensor{
 					&TemperatureSensor{
 						Name:          "temp1",
@@ -244,6 +259,11 @@ func TestScannerScan(t *testing.T) {
 						High:          80.0,
 						Critical:      100.0,
 						CriticalAlarm: false,
+						Attributes: map[string]bool{
+							"crit":       true,
+							"crit_alarm": true,
+							"max":        true,
+						},
 					},
 					&TemperatureSensor{
 						Name:          "temp2",
@@ -252,6 +272,11 @@ func TestScannerScan(t *testing.T) {
 						High:          80.0,
 						Critical:      100.0,
 						CriticalAlarm: false,
+						Attributes: map[string]bool{
+							"crit":       true,
+							"crit_alarm": true,
+							"max":        true,
+						},
 					},
 				},
 			}},
@@ -352,6 +377,18 @@ func TestScannerScan(t *testing.T) {
 						name:     "/sys/devices/platform/it87.2608/intrusion0_alarm",
 						contents: "1",
 					},
+					{
+						name:     "/sys/devices/platform/it87.2608/intrusion0_beep",
+						contents: "1",
+					},
+					{
+						name:     "/sys/devices/platform/it87.2608/pwm1",
+						contents: "128",
+					},
+					{
+						name:     "/sys/devices/platform/it87.2608/pwm1_enable",
+						contents: "2",
+					},
 					{
 						name:     "/sys/devices/platform/it87.2608/temp1_alarm",
 						contents: "0",
@@ -360,10 +397,18 @@ func TestScannerScan(t *testing.T) {
 						name:     "/sys/devices/platform/it87.2608/temp1_beep",
 						contents: "1",
 					},
+					{
+						name:     "/sys/devices/platform/it87.2608/temp1_highest",
+						contents: "51000",
+					},
 					{
 						name:     "/sys/devices/platform/it87.2608/temp1_input",
 						contents: "43000",
 					},
+					{
+						name:     "/sys/devices/platform/it87.2608/temp1_lowest",
+						contents: "31000",
+					},
 					{
 						name:     "/sys/devices/platform/it87.2608/temp1_max",
 						contents: "127000",
@@ -375,7 +420,10 @@ func TestScannerScan(t *testing.T) {
 				},
 			},
 			devices: []*Device{{
-				Name: "it8728-00",
+				Name:       "it8728-00",
+				Path:       "/sys/devices/platform/it87.2608",
+				Hwmon:      "hwmon2",
+				DevicePath: "/sys/devices/platform/it87.2608",
 				Sensors: []Sensor{
 					&FanSensor{
 						Name:    "fan1",
@@ -383,6 +431,11 @@ func TestScannerScan(t *testing.T) {
 						Beep:    true,
 						Input:   1010,
 						Minimum: 10,
+						Attributes: map[string]bool{
+							"alarm": true,
+							"beep":  true,
+							"min":   true,
+						},
 					},
 					&VoltageSensor{
 						Name:    "in0",
@@ -390,6 +443,11 @@ func TestScannerScan(t *testing.T) {
 						Beep:    false,
 						Input:   1.056,
 						Maximum: 3.060,
+						Attributes: map[string]bool{
+							"alarm": true,
+							"beep":  true,
+							"max":   true,
+						},
 					},
 					&VoltageSensor{
 						Name:    "in1",
@@ -398,18 +456,39 @@ func TestScannerScan(t *testing.T) {
 						Beep:    false,
 						Input:   3.384,
 						Maximum: 6.120,
+						Attributes: map[string]bool{
+							"alarm": true,
+							"beep":  true,
+							"max":   true,
+						},
 					},
 					&IntrusionSensor{
 						Name:  "intrusion0",
 						Alarm: true,
+						Beep:  true,
+					},
+					&PWMSensor{
+						Name:   "pwm1",
+						Enable: PWMEnableAutomatic,
+						Duty:   128,
 					},
 					&TemperatureSensor{
-						Name:  "temp1",
-						Alarm: false,
-						Beep:  true,
-						Type:  TemperatureSensorTypeThermistor,
-						Input: 43.0,
-						High:  127.0,
+						Name:    "temp1",
+						Alarm:   false,
+						Beep:    true,
+						Type:    TemperatureSensorTypeThermistor,
+						Input:   43.0,
+						High:    127.0,
+						Lowest:  31.0,
+						Highest: 51.0,
+						Attributes: map[string]bool{
+							"alarm":   true,
+							"beep":    true,
+							"highest": true,
+							"lowest":  true,
+							"max":     true,
+							"type":    true,
+						},
 					},
 				},
 			}},
@@ -566,7 +645,10 @@ func TestScannerScan(t *testing.T) {
 			},
 			devices: []*Device{
 				{
-					Name: "coretemp-00",
+					Name:       "coretemp-00",
+					Path:       "/sys/devices/platform/coretemp.0",
+					Hwmon:      "hwmon1",
+					DevicePath: "/sys/devices/platform/coretemp.0",
 					Sensors: []Sensor{
 						&TemperatureSensor{
 							Name:          "temp1",
@@ -575,6 +657,11 @@ func TestScannerScan(t *testing.T) {
 							High:          80.0,
 							Critical:      100.0,
 							CriticalAlarm: false,
+							Attributes: map[string]bool{
+								"crit":       true,
+								"crit_alarm": true,
+								"max":        true,
+							},
 						},
 						&TemperatureSensor{
 							Name:          "temp2",
@@ -583,11 +670,19 @@ func TestScannerScan(t *testing.T) {
 							High:          80.0,
 							Critical:      100.0,
 							CriticalAlarm: false,
+							Attributes: map[string]bool{
+								"crit":       true,
+								"crit_alarm": true,
+								"max":        true,
+							},
 						},
 					},
 				},
 				{
-					Name: "coretemp-01",
+					Name:       "coretemp-01",
+					Path:       "/sys/devices/platform/coretemp.1",
+					Hwmon:      "hwmon2",
+					DevicePath: "/sys/devices/platform/coretemp.1",
 					Sensors: []Sensor{
 						&TemperatureSensor{
 							Name:          "temp1",
@@ -596,6 +691,11 @@ func TestScannerScan(t *testing.T) {
 							High:          80.0,
 							Critical:      100.0,
 							CriticalAlarm: false,
+							Attributes: map[string]bool{
+								"crit":       true,
+								"crit_alarm": true,
+								"max":        true,
+							},
 						},
 						&TemperatureSensor{
 							Name:          "temp2",
@@ -604,6 +704,11 @@ func TestScannerScan(t *testing.T) {
 							High:          80.0,
 							Critical:      100.0,
 							CriticalAlarm: false,
+							Attributes: map[string]bool{
+								"crit":       true,
+								"crit_alarm": true,
+								"max":        true,
+							},
 						},
 					},
 				},
@@ -672,7 +777,10 @@ func TestScannerScan(t *testing.T) {
 				},
 			},
 			devices: []*Device{{
-				Name: "sfc-00",
+				Name:       "sfc-00",
+				Path:       "/sys/devices/pci0000:00/0000:00:02.0/0000:03:00.0",
+				Hwmon:      "hwmon0",
+				DevicePath: "/sys/devices/pci0000:00/0000:00:02.0/0000:03:00.0",
 				Sensors: []Sensor{
 					&CurrentSensor{
 						Name:     "curr1",
@@ -681,6 +789,322 @@ func TestScannerScan(t *testing.T) {
 						Input:    7.624,
 						Maximum:  16.0,
 						Critical: 18.0,
+						Attributes: map[string]bool{
+							"alarm": true,
+							"crit":  true,
+							"max":   true,
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "MDIO device",
+			fs: &memoryFilesystem{
+				symlinks: map[string]string{
+					"/sys/class/hwmon/hwmon0": "../../devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0",
+					"/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0/device": "../../../stmmac-1:01",
+					"/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/subsystem":           "../../../../../../../bus/mdio_bus",
+				},
+				files: []memoryFile{
+					{
+						name: "/sys/class/hwmon",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name: "/sys/class/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							mode: os.ModeSymlink,
+						},
+					},
+					{
+						name: "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name:     "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0/name",
+						contents: "marvell",
+					},
+					{
+						name:     "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0/temp1_input",
+						contents: "48000",
+					},
+				},
+			},
+			devices: []*Device{{
+				Name:       "marvell-00",
+				Path:       "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01/hwmon/hwmon0",
+				Hwmon:      "hwmon0",
+				DevicePath: "/sys/devices/platform/soc/30be0000.ethernet/mdio_bus/stmmac-1/stmmac-1:01",
+				Sensors: []Sensor{
+					&TemperatureSensor{
+						Name:       "temp1",
+						Input:      48.0,
+						Attributes: map[string]bool{},
+					},
+				},
+				subsystem: "mdio_bus",
+			}},
+		},
+		{
+			name: "unreadable attributes",
+			fs: &memoryFilesystem{
+				symlinks: map[string]string{
+					"/sys/class/hwmon/hwmon0": "../../devices/virtual/hwmon/hwmon0",
+				},
+				files: []memoryFile{
+					{
+						name: "/sys/class/hwmon",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name: "/sys/class/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							mode: os.ModeSymlink,
+						},
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/name",
+						contents: "nct6775",
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0/fan1_input",
+						err: &os.PathError{
+							Op:   "read",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/fan1_input",
+							Err:  syscall.EAGAIN,
+						},
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan1_min",
+						contents: "300",
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0/in0_input",
+						err: &os.PathError{
+							Op:   "read",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/in0_input",
+							Err:  syscall.EOPNOTSUPP,
+						},
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0/temp1_input",
+						err: &os.PathError{
+							Op:   "read",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/temp1_input",
+							Err:  syscall.EIO,
+						},
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp1_max",
+						contents: "80000",
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0/temp2_input",
+						err: &os.PathError{
+							Op:   "read",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/temp2_input",
+							Err:  syscall.ENODATA,
+						},
+					},
+					{
+						// Write-only attributes are skipped
+						name: "/sys/devices/virtual/hwmon/hwmon0/temp2_reset_history",
+						err: &os.PathError{
+							Op:   "open",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/temp2_reset_history",
+							Err:  syscall.EACCES,
+						},
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0/temp3_reset_history",
+						err: &os.PathError{
+							Op:   "open",
+							Path: "/sys/devices/virtual/hwmon/hwmon0/temp3_reset_history",
+							Err:  syscall.EPERM,
+						},
+					},
+				},
+			},
+			devices: []*Device{{
+				Name:  "nct6775-00",
+				Path:  "/sys/devices/virtual/hwmon/hwmon0",
+				Hwmon: "hwmon0",
+				Sensors: []Sensor{
+					&FanSensor{
+						Name:    "fan1",
+						Minimum: 300,
+						Validity: map[string]Validity{
+							"input": {State: StateUnavailable, Errno: syscall.EAGAIN},
+						},
+						Attributes: map[string]bool{
+							"min": true,
+						},
+					},
+					&VoltageSensor{
+						Name: "in0",
+						// Labeled by DefaultConfig
+						Label: "Vcore",
+						Validity: map[string]Validity{
+							"input": {State: StateNotSupported, Errno: syscall.EOPNOTSUPP},
+						},
+						Attributes: map[string]bool{},
+					},
+					&TemperatureSensor{
+						Name: "temp1",
+						High: 80.0,
+						Validity: map[string]Validity{
+							"input": {State: StateIOError, Errno: syscall.EIO},
+						},
+						Attributes: map[string]bool{
+							"max": true,
+						},
+					},
+					&TemperatureSensor{
+						Name: "temp2",
+						Validity: map[string]Validity{
+							"input": {State: StateUnavailable, Errno: syscall.ENODATA},
+						},
+						Attributes: map[string]bool{},
+					},
+				},
+			}},
+		},
+		{
+			name: "faulted readings",
+			fs: &memoryFilesystem{
+				symlinks: map[string]string{
+					"/sys/class/hwmon/hwmon0": "../../devices/virtual/hwmon/hwmon0",
+				},
+				files: []memoryFile{
+					{
+						name: "/sys/class/hwmon",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name: "/sys/class/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							mode: os.ModeSymlink,
+						},
+					},
+					{
+						name: "/sys/devices/virtual/hwmon/hwmon0",
+						info: &memoryFileInfo{
+							isDir: true,
+						},
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/name",
+						contents: "nct6775",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan1_fault",
+						contents: "1",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan1_input",
+						contents: "0",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan2_input",
+						contents: "65535",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan3_fault",
+						contents: "0",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/fan3_input",
+						contents: "1200",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp1_input",
+						contents: "-127000",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp2_input",
+						contents: "127500",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp3_fault",
+						contents: "1",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp3_input",
+						contents: "0",
+					},
+					{
+						name:     "/sys/devices/virtual/hwmon/hwmon0/temp4_input",
+						contents: "36500",
+					},
+				},
+			},
+			devices: []*Device{{
+				Name:  "nct6775-00",
+				Path:  "/sys/devices/virtual/hwmon/hwmon0",
+				Hwmon: "hwmon0",
+				Sensors: []Sensor{
+					&FanSensor{
+						Name: "fan1",
+						Validity: map[string]Validity{
+							"input": {State: StateFaulted},
+						},
+						Attributes: map[string]bool{},
+					},
+					&FanSensor{
+						Name:  "fan2",
+						Input: 65535,
+						Validity: map[string]Validity{
+							"input": {State: StateFaulted},
+						},
+						Attributes: map[string]bool{},
+					},
+					&FanSensor{
+						Name:       "fan3",
+						Input:      1200,
+						Attributes: map[string]bool{},
+					},
+					&TemperatureSensor{
+						Name:  "temp1",
+						Input: -127.0,
+						Validity: map[string]Validity{
+							"input": {State: StateFaulted},
+						},
+						Attributes: map[string]bool{},
+					},
+					&TemperatureSensor{
+						Name:  "temp2",
+						Input: 127.5,
+						Validity: map[string]Validity{
+							"input": {State: StateFaulted},
+						},
+						Attributes: map[string]bool{},
+					},
+					&TemperatureSensor{
+						Name: "temp3",
+						Validity: map[string]Validity{
+							"input": {State: StateFaulted},
+						},
+						Attributes: map[string]bool{},
+					},
+					&TemperatureSensor{
+						Name:       "temp4",
+						Input:      36.5,
+						Attributes: map[string]bool{},
 					},
 				},
 			}},
@@ -696,6 +1120,12 @@ func TestScannerScan(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
+			// Scanned Devices retain the filesystem used to read and write
+			// their attributes
+			for _, d := range tt.devices {
+				d.fs = tt.fs
+			}
+
 			if want, got := tt.devices, devices; !reflect.DeepEqual(want, got) {
 				t.Fatalf("unexpected Devices:\n- want:\n%v\n-  got:\n%v",
 					devicesStr(want), devicesStr(got))
@@ -704,6 +1134,94 @@ func TestScannerScan(t *testing.T) {
 	}
 }
 
+func TestScannerScanResults(t *testing.T) {
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon0": "../../devices/virtual/hwmon/hwmon0",
+			"/sys/class/hwmon/hwmon1": "../../devices/virtual/hwmon/hwmon1",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon0",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: "/sys/devices/virtual/hwmon/hwmon0",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name:     "/sys/devices/virtual/hwmon/hwmon0/name",
+				contents: "acpitz",
+			},
+			{
+				name:     "/sys/devices/virtual/hwmon/hwmon0/temp1_input",
+				contents: "27800",
+			},
+			{
+				name: "/sys/devices/virtual/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name:     "/sys/devices/virtual/hwmon/hwmon1/name",
+				contents: "broken",
+			},
+			{
+				name:     "/sys/devices/virtual/hwmon/hwmon1/fan1_input",
+				contents: "foo",
+			},
+		},
+	}
+
+	s := &Scanner{fs: fs}
+
+	results, err := s.ScanResults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := 2, len(results); want != got {
+		t.Fatalf("unexpected number of results: %d != %d", want, got)
+	}
+
+	ok, bad := results[0], results[1]
+	if ok.Err != nil {
+		t.Fatalf("unexpected error for %q: %v", ok.Device.Name, ok.Err)
+	}
+	if want, got := 1, len(ok.Device.Sensors); want != got {
+		t.Fatalf("unexpected number of sensors for %q: %d != %d", ok.Device.Name, want, got)
+	}
+
+	if want, got := "broken-00", bad.Device.Name; want != got {
+		t.Fatalf("unexpected device name:\n- want: %q\n-  got: %q", want, got)
+	}
+	if bad.Err == nil {
+		t.Fatalf("expected an error for %q, but none occurred", bad.Device.Name)
+	}
+
+	// Scan fails if any Device cannot be scanned
+	if _, err := s.Scan(); err == nil {
+		t.Fatal("expected an error from Scan, but none occurred")
+	}
+}
+
 func devicesStr(ds []*Device) string {
 	var out string
 	for _, d := range ds {
@@ -729,11 +1247,67 @@ type memoryFilesystem struct {
 func (fs *memoryFilesystem) ReadFile(filename string) (string, error) {
 	for _, f := range fs.files {
 		if f.name == filename {
+			if f.err != nil {
+				return "", f.err
+			}
+
 			return f.contents, nil
 		}
 	}
 
-	return "", fmt.Errorf("readfile: file %q not in memory", filename)
+	return "", &os.PathError{
+		Op:   "open",
+		Path: filename,
+		Err:  os.ErrNotExist,
+	}
+}
+
+func (fs *memoryFilesystem) WriteFile(filename, data string) error {
+	for i, f := range fs.files {
+		if f.name != filename {
+			continue
+		}
+
+		if f.writeErr != nil {
+			return f.writeErr
+		}
+
+		// Emulate a driver which rounds or clamps written values
+		if f.onWrite != nil {
+			data = f.onWrite(data)
+		}
+
+		fs.files[i].contents = data
+		return nil
+	}
+
+	return &os.PathError{
+		Op:   "open",
+		Path: filename,
+		Err:  os.ErrNotExist,
+	}
+}
+
+// setContents sets the contents of an existing file.
+func (fs *memoryFilesystem) setContents(filename, contents string) {
+	for i, f := range fs.files {
+		if f.name == filename {
+			fs.files[i].contents = contents
+			return
+		}
+	}
+
+	panic(fmt.Sprintf("setcontents: file %q not in memory", filename))
+}
+
+// contents returns the contents of an existing file.
+func (fs *memoryFilesystem) contents(filename string) string {
+	s, err := fs.ReadFile(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
 }
 
 func (fs *memoryFilesystem) Readlink(name string) (string, error) {
@@ -756,7 +1330,11 @@ func (fs *memoryFilesystem) Stat(name string) (os.FileInfo, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("stat: file %q not in memory", name)
+	return nil, &os.PathError{
+		Op:   "stat",
+		Path: name,
+		Err:  os.ErrNotExist,
+	}
 }
 
 func (fs *memoryFilesystem) Walk(root string, walkFn filepath.WalkFunc) error {
@@ -789,6 +1367,8 @@ type memoryFile struct {
 	contents string
 	info     os.FileInfo
 	err      error
+	writeErr error
+	onWrite  func(data string) string
 }
 
 var _ os.FileInfo = &memoryFileInfo{}
@@ -808,3 +1388,21 @@ func (fi *memoryFileInfo) Mode() os.FileMode  { return fi.mode }
 func (fi *memoryFileInfo) ModTime() time.Time { return fi.modTime }
 func (fi *memoryFileInfo) IsDir() bool        { return fi.isDir }
 func (fi *memoryFileInfo) Sys() interface{}   { return nil }
+
+var _ clock = &fakeClock{}
+
+// A fakeClock is a clock whose timers fire immediately, advancing its
+// current time, used for tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
@@ -11,9 +11,48 @@ type Device struct {
 	// The name of the device.
 	Name string
 
+	// The path to the directory in the /sys filesystem which contains the
+	// device's sensor attributes.
+	Path string
+
+	// The name of the device's directory in /sys/class/hwmon, such as
+	// "hwmon1".
+	Hwmon string
+
+	// The path to the directory in the /sys filesystem of the hardware
+	// which backs the device, such as "/sys/devices/platform/it87.656".
+	// DevicePath is empty for virtual devices.
+	DevicePath string
+
+	// The name of the adapter of the device's i2c bus, such as "SMBus I801
+	// adapter at f040", read from /sys/class/i2c-adapter.  Adapter is empty
+	// for devices on other buses, whose adapters are named after the bus.
+	Adapter string
+
+	// The identity of the machine on which the device was scanned, read
+	// from /sys/class/dmi/id.  DMI is nil if the identity is unavailable.
+	DMI *DMI
+
 	// Any Sensors that belong to this Device.  Use type assertions to
 	// check for specific Sensor types and fetch their data.
 	Sensors []Sensor
+
+	// The filesystem used to read and write sensor attributes, and the
+	// clock used to timestamp changes made to them.
+	fs    filesystem
+	clock clock
+
+	// The compute statements applied to the device's sensors, keyed by
+	// feature name.
+	computes map[string]*ComputeStatement
+
+	// The chip name of a device which was not scanned, but parsed from the
+	// output of the "sensors" command.
+	chipName *ChipName
+
+	// The name of the subsystem of the hardware at DevicePath, such as
+	// "mdio_bus", for buses whose device names are ambiguous.
+	subsystem string
 }
 
 // A Sensor is a hardware sensor, used to retrieve device temperatures,
@@ -23,32 +62,38 @@ type Sensor interface {
 	parse(raw map[string]string) error
 	name() string
 	setName(name string)
+	validity() map[string]Validity
+	setValidity(v map[string]Validity)
 }
 
 // parseSensors parses all Sensors from an input raw data slice, produced
-// during a filesystem walk.
-func parseSensors(raw map[string]map[string]string) ([]Sensor, error) {
+// during a filesystem walk.  Attributes which exist but could not be read
+// are reported in invalid, and are attached to their Sensors.
+func parseSensors(raw map[string]map[string]string, invalid map[string]map[string]Validity) ([]Sensor, error) {
+	// A sensor may exist even if none of its attributes could be read
+	for k := range invalid {
+		if _, ok := raw[k]; !ok {
+			raw[k] = make(map[string]string, 0)
+		}
+	}
+
 	sensors := make([]Sensor, 0, len(raw))
 	for k, v := range raw {
-		var s Sensor
-		switch {
-		case strings.HasPrefix(k, "curr"):
-			s = new(CurrentSensor)
-		case strings.HasPrefix(k, "intrusion"):
-			s = new(IntrusionSensor)
-		case strings.HasPrefix(k, "in"):
-			s = new(VoltageSensor)
-		case strings.HasPrefix(k, "fan"):
-			s = new(FanSensor)
-		case strings.HasPrefix(k, "power"):
-			s = new(PowerSensor)
-		case strings.HasPrefix(k, "temp"):
-			s = new(TemperatureSensor)
-		default:
+		// Sensor names are numbered, e.g. "temp1", so chip-wide attributes
+		// such as "temp_reset_history" are not sensors
+		if k == "" || k[len(k)-1] < '0' || k[len(k)-1] > '9' {
+			continue
+		}
+
+		s := newSensor(k)
+		if s == nil {
 			continue
 		}
 
-		s.setName(k)
+		if vs, ok := invalid[k]; ok {
+			s.setValidity(vs)
+		}
+
 		if err := s.parse(v); err != nil {
 			return nil, err
 		}
@@ -60,6 +105,59 @@ func parseSensors(raw map[string]map[string]string) ([]Sensor, error) {
 	return sensors, nil
 }
 
+// newSensor creates a Sensor of the appropriate type for the sensor name,
+// such as "temp1".  If the name is not recognized, newSensor returns nil.
+func newSensor(name string) Sensor {
+	var s Sensor
+	switch {
+	case strings.HasPrefix(name, "curr"):
+		s = new(CurrentSensor)
+	case strings.HasPrefix(name, "intrusion"):
+		s = new(IntrusionSensor)
+	case strings.HasPrefix(name, "in"):
+		s = new(VoltageSensor)
+	case strings.HasPrefix(name, "fan"):
+		s = new(FanSensor)
+	case strings.HasPrefix(name, "power"):
+		s = new(PowerSensor)
+	case strings.HasPrefix(name, "pwm"):
+		s = new(PWMSensor)
+	case strings.HasPrefix(name, "temp"):
+		s = new(TemperatureSensor)
+	default:
+		return nil
+	}
+
+	s.setName(name)
+
+	// Sensors which are scanned or parsed record their optional attributes,
+	// even if they have none
+	if as, ok := s.(attributeSensor); ok {
+		as.setAttributes(make(map[string]bool, 0))
+	}
+
+	return s
+}
+
+// SensorLabel returns the label of Sensor s, such as "Vcore", or an empty
+// string if s has no label.
+func SensorLabel(s Sensor) string {
+	switch s := s.(type) {
+	case *CurrentSensor:
+		return s.Label
+	case *FanSensor:
+		return s.Label
+	case *PowerSensor:
+		return s.Label
+	case *TemperatureSensor:
+		return s.Label
+	case *VoltageSensor:
+		return s.Label
+	}
+
+	return ""
+}
+
 // byName implements sort.Interface for []Sensor.
 type byName []Sensor
 
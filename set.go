@@ -0,0 +1,169 @@
+package lmsensors
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A SetResult is the result of applying a single "set" statement to a
+// Device.
+type SetResult struct {
+	// The Device to which the statement was applied, and the statement.
+	Device    *Device
+	Statement *SetStatement
+
+	// The value requested by the statement, and the value which was
+	// actually applied by the driver, in the units used by the Sensor's
+	// fields.  Drivers may round or clamp written values to the range
+	// supported by the hardware.
+	Requested float64
+	Applied   float64
+
+	// Any error which occurred while evaluating or writing the value.  If
+	// Err is non-nil, Applied is zero.
+	Err error
+}
+
+// ApplySets applies the "set" statements of Config c to all matching
+// Devices, as "sensors -s" does, and returns the result of each statement.
+// Expressions are evaluated using the readings of the Device after the
+// compute statements of c are applied, and values are converted with the
+// inverse of the compute statement for their feature before they are
+// written.
+//
+// An error is only returned if Devices cannot be scanned; errors which
+// occur while applying individual statements, such as *PermissionError,
+// are reported in each SetResult.
+func (s *Scanner) ApplySets(c *Config) ([]*SetResult, error) {
+	sc := *s
+	sc.Config = c
+
+	devices, err := sc.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*SetResult
+	for _, d := range devices {
+		for _, cb := range c.Chips {
+			if !cb.matches(d) {
+				continue
+			}
+
+			for _, st := range cb.Sets {
+				results = append(results, d.applySet(st))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// applySet evaluates and writes the value of a single "set" statement.
+func (d *Device) applySet(st *SetStatement) *SetResult {
+	r := &SetResult{
+		Device:    d,
+		Statement: st,
+	}
+
+	v, err := st.Value.Eval(0, d.feature)
+	if err != nil {
+		r.Err = &ConfigError{
+			Position: st.Position,
+			Message:  "set " + st.Feature + ": " + err.Error(),
+		}
+		return r
+	}
+	r.Requested = v
+
+	// Attributes are named for their feature, such as "in3_min"; features
+	// without a number, such as "beep_enable", apply to the whole chip
+	feature, attr := st.Feature, ""
+	if i := strings.Index(st.Feature, "_"); i != -1 {
+		feature, attr = st.Feature[:i], st.Feature[i+1:]
+	}
+
+	scale, computed := setAttribute(feature, attr)
+
+	var cs *ComputeStatement
+	if computed {
+		cs = d.computes[feature]
+	}
+
+	if cs != nil {
+		if v, err = cs.To.Eval(v, d.feature); err != nil {
+			r.Err = computeError(cs, err)
+			return r
+		}
+	}
+
+	raw := strconv.FormatInt(int64(math.Round(v*scale)), 10)
+	if err := d.writeAttribute(st.Feature, raw); err != nil {
+		r.Err = err
+		return r
+	}
+
+	// Verify the value which was actually applied by the driver
+	out, err := d.readAttribute(st.Feature)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	f, err := strconv.ParseFloat(out, 64)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	f /= scale
+
+	if cs != nil {
+		if f, err = cs.From.Eval(f, d.feature); err != nil {
+			r.Err = computeError(cs, err)
+			return r
+		}
+	}
+
+	// Keep the Device's Sensors consistent with the written limits
+	for _, s := range d.Sensors {
+		if s.name() != feature {
+			continue
+		}
+
+		if _, set, ok := limitField(s, Limit(attr)); ok {
+			set(f)
+		}
+	}
+
+	r.Applied = f
+	return r
+}
+
+// setAttribute returns the scale factor between the value of a "set"
+// statement and the raw value of the attribute attr of feature, as used by
+// libsensors.  If the attribute is a reading or limit which is transformed
+// by compute statements, rather than a flag such as "beep" or "type",
+// setAttribute also returns true.
+func setAttribute(feature, attr string) (float64, bool) {
+	switch attr {
+	case "alarm", "beep", "div", "enable", "fault", "mode", "pulses", "type":
+		return 1, false
+	}
+
+	if strings.HasSuffix(attr, "interval") {
+		// Time values in milliseconds
+		return 1, false
+	}
+
+	// Fan speeds are whole numbers, and are not transformed by compute
+	// statements
+	switch strings.TrimRight(feature, "0123456789") {
+	case "curr", "in", "temp":
+		return 1000, true
+	case "energy", "power":
+		return 1000000, true
+	}
+
+	return 1, false
+}
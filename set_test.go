@@ -0,0 +1,135 @@
+package lmsensors
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestScannerApplySets(t *testing.T) {
+	const dir = "/sys/devices/platform/it87.656/hwmon/hwmon1"
+
+	fs := &memoryFilesystem{
+		symlinks: map[string]string{
+			"/sys/class/hwmon/hwmon1": "../../devices/platform/it87.656/hwmon/hwmon1",
+			dir + "/device":           "../../../it87.656",
+		},
+		files: []memoryFile{
+			{
+				name: "/sys/class/hwmon",
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{
+				name: "/sys/class/hwmon/hwmon1",
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+			{name: dir + "/name", contents: "it8728"},
+			{name: dir + "/beep_enable", contents: "1"},
+			{name: dir + "/fan1_input", contents: "1200"},
+			{
+				name:     dir + "/fan1_min",
+				contents: "0",
+				onWrite:  func(string) string { return "329" },
+			},
+			{name: dir + "/in0_input", contents: "1200"},
+			{name: dir + "/in3_input", contents: "2688"},
+			{name: dir + "/in3_min", contents: "0"},
+			{
+				name:     dir + "/temp1_max",
+				contents: "127000",
+				writeErr: &os.PathError{Op: "open", Path: dir + "/temp1_max", Err: os.ErrPermission},
+			},
+		},
+	}
+
+	const conf = `
+chip "it8728-isa-0290"
+    compute in3 @*5, @/5
+    # Fans are not computed, so fan1_min is written as is
+    compute fan1 @*2, @/2
+    set in3_min in3 * 0.95
+    set fan1_min 300
+    set beep_enable 0
+    set temp1_max 80
+    set in0_min in9
+
+chip "nct6775-*"
+    set in0_min 1
+`
+
+	c, err := ParseConfig(strings.NewReader(conf), "sensors3.conf")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	s := &Scanner{fs: fs}
+
+	results, err := s.ApplySets(c)
+	if err != nil {
+		t.Fatalf("failed to apply sets: %v", err)
+	}
+
+	if want, got := 5, len(results); want != got {
+		t.Fatalf("unexpected number of results: %d != %d", want, got)
+	}
+
+	tests := []struct {
+		feature   string
+		requested float64
+		applied   float64
+		raw       string
+		perm      bool
+		err       bool
+	}{
+		{feature: "in3_min", requested: 12.768, applied: 12.77, raw: "2554"},
+		{feature: "fan1_min", requested: 300, applied: 329, raw: "329"},
+		{feature: "beep_enable", raw: "0"},
+		{feature: "temp1_max", requested: 80, raw: "127000", perm: true, err: true},
+		{feature: "in0_min", err: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.feature, func(t *testing.T) {
+			r := results[i]
+			if want, got := tt.feature, r.Statement.Feature; want != got {
+				t.Fatalf("unexpected statement: %q != %q", want, got)
+			}
+
+			if tt.err {
+				if r.Err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				if _, ok := r.Err.(*PermissionError); ok != tt.perm {
+					t.Fatalf("unexpected error type: %#v", r.Err)
+				}
+			} else if r.Err != nil {
+				t.Fatalf("unexpected error: %v", r.Err)
+			}
+
+			if want, got := tt.requested, r.Requested; math.Abs(want-got) > 1e-9 {
+				t.Fatalf("unexpected requested value: %v != %v", want, got)
+			}
+			if want, got := tt.applied, r.Applied; math.Abs(want-got) > 1e-9 {
+				t.Fatalf("unexpected applied value: %v != %v", want, got)
+			}
+
+			if tt.raw != "" {
+				if want, got := tt.raw, fs.contents(dir+"/"+tt.feature); want != got {
+					t.Fatalf("unexpected raw value: %q != %q", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package lmsensors
+
+import (
+	"math"
+	"time"
+)
+
+// A Subfeature is a single value of a Sensor, named as it is by libsensors
+// and the /sys filesystem, such as "temp1_input".
+type Subfeature struct {
+	Name  string
+	Value float64
+}
+
+// Subfeatures returns the subfeatures of Sensor s in the order used by
+// libsensors, in the units used by the Sensor's fields.
+//
+// Optional subfeatures, such as limits and alarms, are present if the
+// Sensor's Attributes report them, and readings which are not valid are
+// omitted.  PWMSensors have no subfeatures, as libsensors does not report
+// them.
+func Subfeatures(s Sensor) []Subfeature {
+	var sfs []Subfeature
+
+	// add adds a subfeature if it is present
+	add := func(attr string, v float64, ok bool) {
+		if ok {
+			sfs = append(sfs, Subfeature{Name: s.name() + "_" + attr, Value: v})
+		}
+	}
+
+	valid := func(attr string) bool { return s.validity()[attr].OK() }
+	has := func(attr string, fallback bool) bool { return present(s, attr, fallback) }
+
+	switch s := s.(type) {
+	case *VoltageSensor:
+		add("input", s.Input, valid("input"))
+		add("min", s.Minimum, has("min", s.Minimum != 0))
+		add("max", s.Maximum, has("max", s.Maximum != 0))
+		add("lowest", s.Lowest, has("lowest", s.Lowest != 0))
+		add("highest", s.Highest, has("highest", s.Highest != 0))
+		add("alarm", boolFloat(s.Alarm), has("alarm", true))
+		add("beep", boolFloat(s.Beep), has("beep", s.Beep))
+	case *FanSensor:
+		add("input", float64(s.Input), valid("input"))
+		add("min", float64(s.Minimum), has("min", s.Minimum != 0))
+		add("alarm", boolFloat(s.Alarm), has("alarm", true))
+		add("beep", boolFloat(s.Beep), has("beep", s.Beep))
+	case *TemperatureSensor:
+		add("input", s.Input, valid("input"))
+		add("max", s.High, has("max", s.High != 0))
+		add("crit", s.Critical, has("crit", s.Critical != 0))
+		add("lowest", s.Lowest, has("lowest", s.Lowest != 0))
+		add("highest", s.Highest, has("highest", s.Highest != 0))
+		add("alarm", boolFloat(s.Alarm), has("alarm", true))
+		add("crit_alarm", boolFloat(s.CriticalAlarm), has("crit_alarm", s.Critical != 0 || s.CriticalAlarm))
+		add("type", float64(s.Type), has("type", s.Type != TemperatureSensorUnknown))
+		add("beep", boolFloat(s.Beep), has("beep", s.Beep))
+	case *CurrentSensor:
+		add("input", s.Input, valid("input"))
+		add("max", s.Maximum, has("max", s.Maximum != 0))
+		add("crit", s.Critical, has("crit", s.Critical != 0))
+		add("alarm", boolFloat(s.Alarm), has("alarm", true))
+	case *PowerSensor:
+		add("average", s.Average, valid("average"))
+		add("average_interval", s.AverageInterval.Seconds(), has("average_interval", s.AverageInterval != 0))
+	case *IntrusionSensor:
+		add("alarm", boolFloat(s.Alarm), true)
+		add("beep", 1, s.Beep)
+	}
+
+	return sfs
+}
+
+// setSubfeature sets the field of Sensor s which corresponds to the
+// subfeature attribute attr, such as "input".  If s has no such field,
+// setSubfeature returns false.
+func setSubfeature(s Sensor, attr string, v float64) bool {
+	switch s := s.(type) {
+	case *VoltageSensor:
+		switch attr {
+		case "input":
+			s.Input = v
+		case "min":
+			s.Minimum = v
+		case "max":
+			s.Maximum = v
+		case "lowest":
+			s.Lowest = v
+		case "highest":
+			s.Highest = v
+		case "alarm":
+			s.Alarm = v != 0
+		case "beep":
+			s.Beep = v != 0
+		default:
+			return false
+		}
+	case *FanSensor:
+		switch attr {
+		case "input":
+			s.Input = int(math.Round(v))
+		case "min":
+			s.Minimum = int(math.Round(v))
+		case "alarm":
+			s.Alarm = v != 0
+		case "beep":
+			s.Beep = v != 0
+		default:
+			return false
+		}
+	case *TemperatureSensor:
+		switch attr {
+		case "input":
+			s.Input = v
+		case "max":
+			s.High = v
+		case "crit":
+			s.Critical = v
+		case "lowest":
+			s.Lowest = v
+		case "highest":
+			s.Highest = v
+		case "alarm":
+			s.Alarm = v != 0
+		case "crit_alarm":
+			s.CriticalAlarm = v != 0
+		case "type":
+			s.Type = TemperatureSensorType(v)
+		case "beep":
+			s.Beep = v != 0
+		default:
+			return false
+		}
+	case *CurrentSensor:
+		switch attr {
+		case "input":
+			s.Input = v
+		case "max":
+			s.Maximum = v
+		case "crit":
+			s.Critical = v
+		case "alarm":
+			s.Alarm = v != 0
+		default:
+			return false
+		}
+	case *PowerSensor:
+		switch attr {
+		case "average":
+			s.Average = v
+		case "average_interval":
+			s.AverageInterval = time.Duration(math.Round(v * float64(time.Second)))
+		default:
+			return false
+		}
+	case *IntrusionSensor:
+		switch attr {
+		case "alarm":
+			s.Alarm = v != 0
+		case "beep":
+			s.Beep = v != 0
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+
+	if attr != inputAttribute(s) {
+		setPresent(s, attr)
+	}
+
+	return true
+}
+
+// An attributeSensor is a Sensor with optional attributes, such as limits,
+// whose presence is recorded in its Attributes field.
+type attributeSensor interface {
+	Sensor
+	attributes() map[string]bool
+	setAttributes(a map[string]bool)
+}
+
+// present reports whether the optional attribute attr of Sensor s is
+// provided by its device.  Sensors which record no attributes, such as
+// those created by hand, report fallback instead.
+func present(s Sensor, attr string, fallback bool) bool {
+	as, ok := s.(attributeSensor)
+	if !ok || as.attributes() == nil {
+		return fallback
+	}
+
+	return as.attributes()[attr]
+}
+
+// setPresent records that the optional attribute attr of Sensor s is
+// provided by its device.
+func setPresent(s Sensor, attr string) {
+	as, ok := s.(attributeSensor)
+	if !ok {
+		return
+	}
+
+	a := as.attributes()
+	if a == nil {
+		a = make(map[string]bool, 0)
+		as.setAttributes(a)
+	}
+
+	a[attr] = true
+}
+
+// boolFloat returns the value of a boolean subfeature.
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
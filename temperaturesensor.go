@@ -54,15 +54,36 @@ type TemperatureSensor struct {
 
 	// Whether or not the temperature is past the critical threshold.
 	CriticalAlarm bool
+
+	// The lowest and highest temperatures, in degrees Celsius, recorded by
+	// the sensor since boot or since its history was reset.  Use
+	// Device.ResetHistory to reset them.
+	Lowest  float64
+	Highest float64
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
+
+	// The optional attributes provided by the sensor's device, such as "crit"
+	// or "crit_alarm", keyed by attribute name.  Optional attributes which
+	// are present are reported even if their values are zero.  If nil, limits
+	// are only reported if their values are nonzero.
+	Attributes map[string]bool
 }
 
-func (s *TemperatureSensor) name() string        { return s.Name }
-func (s *TemperatureSensor) setName(name string) { s.Name = name }
+func (s *TemperatureSensor) name() string                      { return s.Name }
+func (s *TemperatureSensor) setName(name string)               { s.Name = name }
+func (s *TemperatureSensor) validity() map[string]Validity     { return s.Validity }
+func (s *TemperatureSensor) setValidity(v map[string]Validity) { s.Validity = v }
+func (s *TemperatureSensor) attributes() map[string]bool       { return s.Attributes }
+func (s *TemperatureSensor) setAttributes(a map[string]bool)   { s.Attributes = a }
 
 func (s *TemperatureSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
 		switch k {
-		case "input", "crit", "max":
+		case "input", "crit", "max", "lowest", "highest":
 			f, err := strconv.ParseFloat(v, 64)
 			if err != nil {
 				return err
@@ -78,11 +99,21 @@ func (s *TemperatureSensor) parse(raw map[string]string) error {
 				s.Critical = f
 			case "max":
 				s.High = f
+			case "lowest":
+				s.Lowest = f
+			case "highest":
+				s.Highest = f
+			}
+
+			if k != "input" {
+				setPresent(s, k)
 			}
 		case "alarm":
 			s.Alarm = v != "0"
+			setPresent(s, k)
 		case "beep":
 			s.Beep = v != "0"
+			setPresent(s, k)
 		case "type":
 			t, err := strconv.Atoi(v)
 			if err != nil {
@@ -90,8 +121,16 @@ func (s *TemperatureSensor) parse(raw map[string]string) error {
 			}
 
 			s.Type = TemperatureSensorType(t)
+			setPresent(s, k)
 		case "crit_alarm":
 			s.CriticalAlarm = v != "0"
+			setPresent(s, k)
+		case "fault":
+			// The driver reports that the sensor is faulty, e.g. a
+			// disconnected thermal diode
+			if v != "0" {
+				invalidate(s, "input", Validity{State: StateFaulted})
+			}
 		case "label":
 			s.Label = v
 		}
@@ -0,0 +1,303 @@
+package lmsensors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A TextEncoder writes Devices in the human-readable text format of the
+// lm-sensors "sensors" command.
+type TextEncoder struct {
+	// Fahrenheit specifies that temperatures are written in degrees
+	// Fahrenheit rather than Celsius, as "sensors -f" does.
+	Fahrenheit bool
+
+	w io.Writer
+}
+
+// NewTextEncoder creates a TextEncoder which writes to w.
+func NewTextEncoder(w io.Writer) *TextEncoder {
+	return &TextEncoder{w: w}
+}
+
+// Encode writes devices to the TextEncoder's io.Writer.  Each Device is
+// written as a chip name header and an adapter line, followed by one line
+// per Sensor and a blank line.  PWMSensors are not written, as "sensors"
+// does not display them.
+func (e *TextEncoder) Encode(devices []*Device) error {
+	bw := bufio.NewWriter(e.w)
+
+	for _, d := range devices {
+		fmt.Fprintln(bw, d.ChipName())
+		if a := d.adapter(); a != "" {
+			fmt.Fprintf(bw, "Adapter: %s\n", a)
+		}
+
+		var labels, lines []string
+		for _, s := range sortedSensors(d.Sensors) {
+			if line := e.sensorLine(s); line != "" {
+				labels = append(labels, sensorLabel(s))
+				lines = append(lines, line)
+			}
+		}
+
+		// Labels are aligned to the longest label, with a minimum width
+		// so short labels line up across chips
+		width := 11
+		for _, l := range labels {
+			if len(l) > width {
+				width = len(l)
+			}
+		}
+		width += 2
+
+		for i := range lines {
+			fmt.Fprintf(bw, "%-*s%s\n", width, labels[i]+":", lines[i])
+		}
+
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// sensorLine formats the value, limits, and alarms of Sensor s.  If s is
+// not displayed, sensorLine returns the empty string.
+func (e *TextEncoder) sensorLine(s Sensor) string {
+	var (
+		value  string
+		limits []string
+		alarm  string
+		extra  string
+	)
+
+	ok := s.validity()["input"].OK()
+
+	switch s := s.(type) {
+	case *VoltageSensor:
+		value = "     N/A  "
+		if ok {
+			v, unit := scaleValue(s.Input, "V")
+			value = fmt.Sprintf("%6.2f %-2s ", v, unit)
+		}
+
+		if present(s, "min", s.Minimum != 0) {
+			limits = append(limits, fmt.Sprintf("min = %+6.2f V", s.Minimum))
+		}
+		if present(s, "max", s.Maximum != 0) {
+			limits = append(limits, fmt.Sprintf("max = %+6.2f V", s.Maximum))
+		}
+
+		if s.Alarm {
+			alarm = "ALARM"
+		}
+	case *FanSensor:
+		value = "     N/A  "
+		if ok {
+			value = fmt.Sprintf("%4d RPM  ", s.Input)
+		}
+
+		if present(s, "min", s.Minimum != 0) {
+			limits = append(limits, fmt.Sprintf("min = %4d RPM", s.Minimum))
+		}
+
+		if s.Alarm {
+			alarm = "ALARM"
+		}
+	case *TemperatureSensor:
+		unit := "°C"
+		temp := func(v float64) float64 { return v }
+		if e.Fahrenheit {
+			unit = "°F"
+			temp = func(v float64) float64 { return v*9/5 + 32 }
+		}
+
+		value = "     N/A  "
+		if ok {
+			value = fmt.Sprintf("%+6.1f%s  ", temp(s.Input), unit)
+		}
+
+		if present(s, "max", s.High != 0) {
+			limits = append(limits, fmt.Sprintf("high = %+5.1f%s", temp(s.High), unit))
+		}
+		if present(s, "crit", s.Critical != 0) {
+			limits = append(limits, fmt.Sprintf("crit = %+5.1f%s", temp(s.Critical), unit))
+		}
+
+		switch {
+		case s.CriticalAlarm:
+			alarm = "ALARM (CRIT)"
+		case s.Alarm:
+			alarm = "ALARM"
+		}
+
+		if t, ok := temperatureTypes[s.Type]; ok {
+			extra = "sensor = " + t
+		}
+	case *CurrentSensor:
+		value = "     N/A  "
+		if ok {
+			v, unit := scaleValue(s.Input, "A")
+			value = fmt.Sprintf("%6.2f %-2s ", v, unit)
+		}
+
+		if present(s, "max", s.Maximum != 0) {
+			limits = append(limits, fmt.Sprintf("max = %+6.2f A", s.Maximum))
+		}
+		if present(s, "crit", s.Critical != 0) {
+			limits = append(limits, fmt.Sprintf("crit = %+6.2f A", s.Critical))
+		}
+
+		if s.Alarm {
+			alarm = "ALARM"
+		}
+	case *PowerSensor:
+		value = "     N/A  "
+		if s.validity()["average"].OK() {
+			v, unit := scaleValue(s.Average, "W")
+			value = fmt.Sprintf("%6.2f %-2s ", v, unit)
+		}
+
+		if present(s, "average_interval", s.AverageInterval != 0) {
+			limits = append(limits, fmt.Sprintf("interval = %6.2f s", s.AverageInterval.Seconds()))
+		}
+	case *IntrusionSensor:
+		if s.Alarm {
+			return "ALARM"
+		}
+
+		return "OK"
+	default:
+		return ""
+	}
+
+	line := value
+	if len(limits) > 0 {
+		line += "(" + strings.Join(limits, ", ") + ")"
+	}
+	if alarm != "" {
+		line += "  " + alarm
+	}
+	if extra != "" {
+		line += "  " + extra
+	}
+
+	return line
+}
+
+// adapter returns the name of the adapter of Device d, as reported by
+// libsensors.  If the name is not known, adapter returns the empty string.
+func (d *Device) adapter() string {
+	if d.Adapter != "" {
+		return d.Adapter
+	}
+
+	return adapterName(d.ChipName().Bus)
+}
+
+// adapterName returns the name of the adapter for a bus type, as reported
+// by libsensors.  Each i2c adapter has its own name, so adapterName returns
+// the empty string for them.
+func adapterName(b BusType) string {
+	switch b {
+	case BusISA:
+		return "ISA adapter"
+	case BusPCI:
+		return "PCI adapter"
+	case BusSPI:
+		return "SPI adapter"
+	case BusVirtual:
+		return "Virtual device"
+	case BusACPI:
+		return "ACPI interface"
+	case BusHID:
+		return "HID adapter"
+	case BusSCSI:
+		return "SCSI adapter"
+	case BusMDIO:
+		return "MDIO adapter"
+	}
+
+	return ""
+}
+
+// temperatureTypes maps TemperatureSensorTypes to the names used by
+// "sensors".
+var temperatureTypes = map[TemperatureSensorType]string{
+	TemperatureSensorTypePIICeleronDiode: "CPU diode",
+	TemperatureSensorType3904Transistor:  "transistor",
+	TemperatureSensorTypeThermalDiode:    "thermal diode",
+	TemperatureSensorTypeThermistor:      "thermistor",
+	TemperatureSensorTypeAMDAMDSI:        "AMD AMDSI",
+	TemperatureSensorTypeIntelPECI:       "Intel PECI",
+}
+
+// scaleValue scales v by an SI prefix so that its magnitude is at least 1
+// and less than 1000, and returns the scaled value and its unit.
+func scaleValue(v float64, unit string) (float64, string) {
+	prefixes := []string{"p", "n", "u", "m", "", "k", "M", "G", "T"}
+
+	i := 4
+	a := math.Abs(v)
+	for ; a != 0 && a < 1 && i > 0; i-- {
+		a *= 1000
+		v *= 1000
+	}
+	for ; a >= 1000 && i < len(prefixes)-1; i++ {
+		a /= 1000
+		v /= 1000
+	}
+
+	return v, prefixes[i] + unit
+}
+
+// sensorLabel returns the label of Sensor s, or its name if it has no
+// label.
+func sensorLabel(s Sensor) string {
+	if l := SensorLabel(s); l != "" {
+		return l
+	}
+
+	return s.name()
+}
+
+// sensorKinds orders Sensor name prefixes as libsensors orders features.
+var sensorKinds = []string{"in", "fan", "temp", "power", "curr", "intrusion", "pwm"}
+
+// sortedSensors returns a copy of sensors in the order used by libsensors:
+// by kind, and then by number.
+func sortedSensors(sensors []Sensor) []Sensor {
+	out := make([]Sensor, len(sensors))
+	copy(out, sensors)
+
+	key := func(s Sensor) (int, int) {
+		name := s.name()
+		prefix := strings.TrimRight(name, "0123456789")
+		n, _ := strconv.Atoi(name[len(prefix):])
+
+		for i, k := range sensorKinds {
+			if k == prefix {
+				return i, n
+			}
+		}
+
+		return len(sensorKinds), n
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		ki, ni := key(out[i])
+		kj, nj := key(out[j])
+		if ki != kj {
+			return ki < kj
+		}
+
+		return ni < nj
+	})
+
+	return out
+}
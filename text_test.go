@@ -0,0 +1,195 @@
+package lmsensors
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// testFilesystem returns a /sys filesystem with the hwmon devices of a
+// machine, used to test the lm-sensors output formats.  The golden files in
+// testdata hold the output expected of "sensors" for these devices.  They
+// follow the output formats of lm-sensors, but were not captured from real
+// hardware.
+func testFilesystem() *memoryFilesystem {
+	fs := &memoryFilesystem{
+		symlinks: make(map[string]string),
+		files: []memoryFile{{
+			name: "/sys/class/hwmon",
+			info: &memoryFileInfo{
+				isDir: true,
+			},
+		}},
+	}
+
+	// add adds an hwmon device in directory dir with the specified
+	// attributes, linked to the hardware in directory device, if any
+	add := func(hwmon, dir, device string, attrs []memoryFile) {
+		fs.symlinks["/sys/class/hwmon/"+hwmon] = "../../" + strings.TrimPrefix(dir, "/sys/")
+		if device != "" {
+			fs.symlinks[dir+"/device"] = device
+		}
+
+		fs.files = append(fs.files,
+			memoryFile{
+				name: "/sys/class/hwmon/" + hwmon,
+				info: &memoryFileInfo{
+					mode: os.ModeSymlink,
+				},
+			},
+			memoryFile{
+				name: dir,
+				info: &memoryFileInfo{
+					isDir: true,
+				},
+			},
+		)
+
+		for _, a := range attrs {
+			a.name = dir + "/" + a.name
+			fs.files = append(fs.files, a)
+		}
+	}
+
+	add("hwmon0", "/sys/devices/platform/coretemp.0/hwmon/hwmon0", "../../../coretemp.0", []memoryFile{
+		{name: "name", contents: "coretemp"},
+		{name: "temp1_label", contents: "Package id 0"},
+		{name: "temp1_input", contents: "43000"},
+		{name: "temp1_max", contents: "80000"},
+		{name: "temp1_crit", contents: "100000"},
+		{name: "temp1_crit_alarm", contents: "0"},
+		{name: "temp2_label", contents: "Core 0"},
+		{name: "temp2_input", contents: "41000"},
+		{name: "temp2_max", contents: "80000"},
+		{name: "temp2_crit", contents: "100000"},
+		{name: "temp2_crit_alarm", contents: "0"},
+	})
+
+	add("hwmon1", "/sys/devices/platform/it87.2608/hwmon/hwmon1", "../../../it87.2608", []memoryFile{
+		{name: "name", contents: "it8728"},
+		{name: "in0_input", contents: "696"},
+		{name: "in0_min", contents: "0"},
+		{name: "in0_max", contents: "3060"},
+		{name: "in0_alarm", contents: "0"},
+		{name: "in0_beep", contents: "0"},
+		{name: "in1_input", contents: "1020"},
+		{name: "in1_min", contents: "0"},
+		{name: "in1_max", contents: "500"},
+		{name: "in1_alarm", contents: "1"},
+		{name: "in1_beep", contents: "0"},
+		{name: "in8_label", contents: "Vbat"},
+		{name: "in8_input", contents: "3216"},
+		{name: "fan1_input", contents: "1130"},
+		{name: "fan1_min", contents: "0"},
+		{name: "fan1_alarm", contents: "0"},
+		{name: "fan1_beep", contents: "0"},
+		{name: "fan2_input", err: syscall.EAGAIN},
+		{name: "fan2_min", contents: "300"},
+		{name: "fan2_alarm", contents: "1"},
+		{name: "fan2_beep", contents: "0"},
+		{name: "temp1_input", contents: "43000"},
+		{name: "temp1_max", contents: "127000"},
+		{name: "temp1_type", contents: "4"},
+		{name: "temp1_alarm", contents: "0"},
+		{name: "temp1_beep", contents: "1"},
+		{name: "temp2_input", contents: "101500"},
+		{name: "temp2_max", contents: "90000"},
+		{name: "temp2_crit", contents: "100000"},
+		{name: "temp2_type", contents: "3"},
+		{name: "temp2_alarm", contents: "1"},
+		{name: "temp2_crit_alarm", contents: "1"},
+		{name: "temp2_beep", contents: "0"},
+		{name: "intrusion0_alarm", contents: "1"},
+		{name: "pwm1", contents: "128"},
+		{name: "pwm1_enable", contents: "2"},
+	})
+
+	add("hwmon2", "/sys/devices/virtual/thermal/thermal_zone0/hwmon2", "", []memoryFile{
+		{name: "name", contents: "acpitz"},
+		{name: "temp1_input", contents: "27800"},
+		{name: "temp1_crit", contents: "105000"},
+	})
+
+	add("hwmon3", "/sys/devices/LNXSYSTM:00/device:00/ACPI000D:00/hwmon/hwmon3", "../../../ACPI000D:00", []memoryFile{
+		{name: "name", contents: "power_meter"},
+		{name: "power1_average", contents: "345000000"},
+		{name: "power1_average_interval", contents: "1000"},
+		{name: "power1_is_battery", contents: "0"},
+	})
+
+	add("hwmon4", "/sys/devices/pci0000:00/0000:00:1f.4/i2c-0/0-0048/hwmon/hwmon4", "../../../0-0048", []memoryFile{
+		{name: "name", contents: "lm75"},
+		{name: "temp1_input", contents: "31500"},
+		{name: "temp1_max", contents: "80000"},
+		{name: "temp1_max_hyst", contents: "75000"},
+	})
+
+	fs.files = append(fs.files, memoryFile{
+		name:     "/sys/class/i2c-adapter/i2c-0/name",
+		contents: "SMBus I801 adapter at f040",
+	})
+
+	return fs
+}
+
+// testDevices returns Devices used to test the lm-sensors output formats,
+// scanned from testFilesystem.
+func testDevices(t *testing.T) []*Device {
+	t.Helper()
+
+	devices, err := (&Scanner{fs: testFilesystem()}).Scan()
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	// Devices are encoded and decoded without the filesystem used to scan
+	// them
+	for _, d := range devices {
+		d.fs = nil
+	}
+
+	return devices
+}
+
+func TestTextEncoder(t *testing.T) {
+	tests := []struct {
+		name       string
+		fahrenheit bool
+		golden     string
+	}{
+		{
+			name:   "celsius",
+			golden: "sensors.golden",
+		},
+		{
+			name:       "fahrenheit",
+			fahrenheit: true,
+			golden:     "sensors-f.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewTextEncoder(&buf)
+			e.Fahrenheit = tt.fahrenheit
+
+			if err := e.Encode(testDevices(t)); err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+
+			want, err := ioutil.ReadFile(filepath.Join("testdata", tt.golden))
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+
+			if got := buf.Bytes(); !bytes.Equal(want, got) {
+				t.Fatalf("unexpected output:\n- want:\n%s\n-  got:\n%s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package lmsensors
+
+import (
+	"os"
+	"syscall"
+)
+
+// A State is a value that indicates whether or not a sensor attribute
+// could be read.
+type State int
+
+// All possible State constants.
+const (
+	// The attribute was read successfully.
+	StateOK State = 0
+
+	// The attribute has no data available, or the device is not ready to
+	// report it (ENODATA, EAGAIN).
+	StateUnavailable State = 1
+
+	// The device reported an I/O error while reading the attribute (EIO).
+	StateIOError State = 2
+
+	// The device does not support reading the attribute (EOPNOTSUPP).
+	StateNotSupported State = 3
+
+	// The attribute was read, but the driver reported a fault, or the
+	// reading is implausible.
+	StateFaulted State = 4
+)
+
+// String returns the string representation of a State.
+func (s State) String() string {
+	switch s {
+	case StateOK:
+		return "ok"
+	case StateUnavailable:
+		return "unavailable"
+	case StateIOError:
+		return "I/O error"
+	case StateNotSupported:
+		return "not supported"
+	case StateFaulted:
+		return "faulted"
+	default:
+		return "unknown"
+	}
+}
+
+// A Validity describes whether or not a sensor attribute holds a valid
+// reading, and if not, the error number reported by the kernel.
+//
+// The zero value of a Validity indicates a valid reading, so a lookup for
+// an attribute that is not present in a Sensor's Validity map reports
+// StateOK.
+type Validity struct {
+	// The state of the attribute.
+	State State
+
+	// The error number reported by the kernel, if the attribute could not
+	// be read.
+	Errno syscall.Errno
+}
+
+// OK reports whether or not the attribute holds a valid reading.
+func (v Validity) OK() bool { return v.State == StateOK }
+
+// newValidity creates a Validity from an error returned while reading a
+// sensor attribute.  If the error does not indicate that the attribute
+// exists but holds no valid reading, such as a missing or write-only
+// attribute, newValidity returns false.
+func newValidity(err error) (Validity, bool) {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+
+	errno, ok := err.(syscall.Errno)
+	if !ok {
+		return Validity{}, false
+	}
+
+	var state State
+	switch errno {
+	case syscall.ENODATA, syscall.EAGAIN:
+		state = StateUnavailable
+	case syscall.EIO:
+		state = StateIOError
+	case syscall.EOPNOTSUPP:
+		state = StateNotSupported
+	default:
+		// Permission errors and the like are reported for attributes
+		// which are not meant to be read, such as temp1_reset_history
+		return Validity{}, false
+	}
+
+	return Validity{
+		State: state,
+		Errno: errno,
+	}, true
+}
+
+// invalidate marks the attribute attr of Sensor s with Validity v.
+func invalidate(s Sensor, attr string, v Validity) {
+	vs := s.validity()
+	if vs == nil {
+		vs = make(map[string]Validity, 0)
+		s.setValidity(vs)
+	}
+
+	vs[attr] = v
+}
@@ -25,17 +25,40 @@ type VoltageSensor struct {
 	// The input voltage indicated by the sensor.
 	Input float64
 
+	// The minimum voltage threshold indicated by the sensor.
+	Minimum float64
+
 	// The maximum voltage threshold indicated by the sensor.
 	Maximum float64
+
+	// The lowest and highest voltages recorded by the sensor since boot or
+	// since its history was reset.  Use Device.ResetHistory to reset them.
+	Lowest  float64
+	Highest float64
+
+	// The validity of any attributes which could not be read, keyed by
+	// attribute name, such as "input".  Attributes which are not present
+	// were read successfully.
+	Validity map[string]Validity
+
+	// The optional attributes provided by the sensor's device, such as "min"
+	// or "alarm", keyed by attribute name.  Optional attributes which are
+	// present are reported even if their values are zero.  If nil, limits are
+	// only reported if their values are nonzero.
+	Attributes map[string]bool
 }
 
-func (s *VoltageSensor) name() string        { return s.Name }
-func (s *VoltageSensor) setName(name string) { s.Name = name }
+func (s *VoltageSensor) name() string                      { return s.Name }
+func (s *VoltageSensor) setName(name string)               { s.Name = name }
+func (s *VoltageSensor) validity() map[string]Validity     { return s.Validity }
+func (s *VoltageSensor) setValidity(v map[string]Validity) { s.Validity = v }
+func (s *VoltageSensor) attributes() map[string]bool       { return s.Attributes }
+func (s *VoltageSensor) setAttributes(a map[string]bool)   { s.Attributes = a }
 
 func (s *VoltageSensor) parse(raw map[string]string) error {
 	for k, v := range raw {
 		switch k {
-		case "input", "max":
+		case "input", "min", "max", "lowest", "highest":
 			f, err := strconv.ParseFloat(v, 64)
 			if err != nil {
 				return err
@@ -47,13 +70,25 @@ func (s *VoltageSensor) parse(raw map[string]string) error {
 			switch k {
 			case "input":
 				s.Input = f
+			case "min":
+				s.Minimum = f
 			case "max":
 				s.Maximum = f
+			case "lowest":
+				s.Lowest = f
+			case "highest":
+				s.Highest = f
+			}
+
+			if k != "input" {
+				setPresent(s, k)
 			}
 		case "alarm":
 			s.Alarm = v != "0"
+			setPresent(s, k)
 		case "beep":
 			s.Beep = v != "0"
+			setPresent(s, k)
 		case "label":
 			s.Label = v
 		}